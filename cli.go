@@ -0,0 +1,497 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"agent-search/github"
+)
+
+// ============================
+// Non-interactive Subcommands
+// ============================
+
+// subcommands run without the TUI, for scripts and other tools
+var subcommands = map[string]func(args []string) error{
+	"search":  runSearch,
+	"install": runInstall,
+	"update":  runUpdate,
+	"undo":    runUndo,
+}
+
+// errUsage means the arguments were wrong; the flag set has already said why
+var errUsage = errors.New("usage")
+
+// runSubcommand runs a subcommand and returns the process exit code
+func runSubcommand(args []string) int {
+	run, ok := subcommands[args[0]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "agentdl: unknown command %q\n", args[0])
+		flag.Usage()
+		return 2
+	}
+
+	if err := run(args[1:]); err != nil {
+		if errors.Is(err, errUsage) || errors.Is(err, flag.ErrHelp) {
+			return 2
+		}
+		fmt.Fprintf(os.Stderr, "agentdl %s: %v\n", args[0], err)
+		if !errors.Is(err, context.Canceled) {
+			fmt.Fprintln(os.Stderr, errorHint(err))
+		}
+		return 1
+	}
+	return 0
+}
+
+// runSearch implements `agentdl search <keywords> [flags]`
+func runSearch(args []string) error {
+	fs := flag.NewFlagSet("search", flag.ContinueOnError)
+	mode := fs.String("mode", "agents", "what to search for: "+kindSlugs())
+	match := fs.String("match", "all", "keywords must all match (all) or any may match (any)")
+	limit := fs.Int("limit", 100, "maximum number of results")
+	format := fs.String("format", "table", "output format: table, json or ndjson")
+	noCache := fs.Bool("no-cache", false, "bypass the on-disk cache")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: agentdl search <keywords> [flags]")
+		fs.PrintDefaults()
+	}
+
+	keywords, err := parseInterleaved(fs, args)
+	if err != nil {
+		return err
+	}
+
+	kind, ok := github.KindBySlug(*mode)
+	if !ok {
+		fmt.Fprintf(fs.Output(), "unknown --mode %q; want one of %s\n", *mode, kindSlugs())
+		return errUsage
+	}
+	if *match != "all" && *match != "any" {
+		fmt.Fprintf(fs.Output(), "unknown --match %q; want all or any\n", *match)
+		return errUsage
+	}
+	if *format != "table" && *format != "json" && *format != "ndjson" {
+		fmt.Fprintf(fs.Output(), "unknown --format %q; want table, json or ndjson\n", *format)
+		return errUsage
+	}
+	if *limit < 0 {
+		fmt.Fprintf(fs.Output(), "--limit %d is negative\n", *limit)
+		return errUsage
+	}
+	if *noCache {
+		github.DefaultCache.Disabled = true
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	results, err := github.Search(ctx, strings.Join(keywords, " "), github.SearchOptions{
+		MatchMode:  *match,
+		SearchMode: kind.Mode,
+		Limit:      *limit,
+	})
+
+	// Print whatever was found, even when the search stopped early
+	if werr := writeResults(os.Stdout, results, *format); werr != nil {
+		return werr
+	}
+	return err
+}
+
+// runInstall implements `agentdl install owner/repo[:path][@ref]... [flags]`
+func runInstall(args []string) error {
+	fs := flag.NewFlagSet("install", flag.ContinueOnError)
+	mode := fs.String("mode", "agents", "what to install: "+kindSlugs())
+	to := fs.String("to", "global", "where to install: global, project or a directory")
+	onConflict := fs.String("on-conflict", "skip", "when a file is already there: "+policyNames())
+	dryRun := fs.Bool("dry-run", false, "show what would be written without installing anything")
+	noCache := fs.Bool("no-cache", false, "bypass the on-disk cache")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: agentdl install owner/repo[:path][@ref]... [flags]")
+		fmt.Fprintln(fs.Output(), "Without a path, everything in the repository's directory for --mode is installed.")
+		fs.PrintDefaults()
+	}
+
+	specs, err := parseInterleaved(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(specs) == 0 {
+		fs.Usage()
+		return errUsage
+	}
+
+	kind, ok := github.KindBySlug(*mode)
+	if !ok {
+		fmt.Fprintf(fs.Output(), "unknown --mode %q; want one of %s\n", *mode, kindSlugs())
+		return errUsage
+	}
+	policy, ok := parseCollisionPolicy(*onConflict)
+	if !ok {
+		fmt.Fprintf(fs.Output(), "unknown --on-conflict %q; want one of %s\n", *onConflict, policyNames())
+		return errUsage
+	}
+	location := installLocation(*to, kind.Mode)
+	if *noCache {
+		github.DefaultCache.Disabled = true
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	failed := 0
+	for _, spec := range specs {
+		selections, err := resolveInstallSpec(ctx, spec, kind)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", spec, err)
+			failed++
+			continue
+		}
+
+		collisions := findCollisions(selections, location, kind)
+		for _, c := range collisions {
+			fmt.Fprintf(os.Stderr, "%s: collision: %s\n", spec, c.describe(selections, location))
+		}
+		install, skipped := resolveCollisions(selections, collisions, policy, location, kind)
+		for _, sel := range skipped {
+			fmt.Fprintf(os.Stderr, "%s: skipped %s\n", spec, sel.Path)
+		}
+
+		if *dryRun {
+			if !printPlan(ctx, spec, install, location, kind.Mode) {
+				failed++
+			}
+			continue
+		}
+
+		results, err := installSelections(ctx, install, location, kind.Mode, nil)
+		for _, r := range results {
+			if r.status == downloadFailed {
+				fmt.Fprintf(os.Stderr, "%s: failed %s: %v\n", spec, r.sel.Path, r.err)
+			}
+		}
+		installed := countResults(results, downloadSaved)
+		summary := fmt.Sprintf("%s: installed %d of %d into %s", spec, installed, len(install), location)
+		if len(skipped) > 0 {
+			summary += fmt.Sprintf(" (%d skipped)", len(skipped))
+		}
+		fmt.Println(summary)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: nothing installed: %v\n", spec, err)
+		}
+		if installed < len(install) || err != nil {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d failed", failed, len(specs))
+	}
+	return nil
+}
+
+// printPlan prints what installing the selections would write, reporting
+// whether every selection could be planned
+func printPlan(ctx context.Context, spec string, selections []GlobalSelection, location string, mode searchMode) bool {
+	plan, results, err := planSelections(ctx, selections, location, mode)
+	ok := err == nil
+	for _, r := range results {
+		if r.status == downloadFailed {
+			fmt.Fprintf(os.Stderr, "%s: failed %s: %v\n", spec, r.sel.Path, r.err)
+			ok = false
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", spec, err)
+		return false
+	}
+
+	fmt.Printf("%s: would write %d files (%s) into %s\n", spec, len(plan), formatBytes(planBytes(plan)), location)
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, p := range plan {
+		stat := ""
+		if p.change == planModified {
+			stat = fmt.Sprintf("+%d -%d", p.added, p.removed)
+		}
+		fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\n", p.change, stat, formatBytes(p.size), p.dest)
+	}
+	tw.Flush()
+	return ok
+}
+
+// runUpdate implements `agentdl update [flags]`
+func runUpdate(args []string) error {
+	fs := flag.NewFlagSet("update", flag.ContinueOnError)
+	to := fs.String("to", "all", "which installs to update: all, global, project or a directory")
+	yes := fs.Bool("yes", false, "apply every update without asking")
+	check := fs.Bool("check", false, "list available updates without applying them")
+	noCache := fs.Bool("no-cache", false, "bypass the on-disk cache")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: agentdl update [flags]")
+		fmt.Fprintln(fs.Output(), "Shows each changed file's diff against the local copy and asks before applying it.")
+		fs.PrintDefaults()
+	}
+
+	rest, err := parseInterleaved(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) > 0 {
+		fs.Usage()
+		return errUsage
+	}
+	if *noCache {
+		github.DefaultCache.Disabled = true
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	updates, checkErr := checkUpdates(ctx, lockFiles(*to))
+	if checkErr != nil {
+		fmt.Fprintln(os.Stderr, checkErr)
+	}
+	if len(updates) == 0 {
+		fmt.Println("Everything is up to date")
+		return checkErr
+	}
+
+	in := bufio.NewReader(os.Stdin)
+	var accepted []pendingUpdate
+	for _, u := range updates {
+		added, removed := diffStat(u.diff)
+		fmt.Printf("%s: %s → %s (+%d -%d)\n", u.title(), shortSHA(u.entry.Commit), shortSHA(u.commit), added, removed)
+		if *check {
+			continue
+		}
+		if u.modified {
+			fmt.Println("  warning: the local copy has been edited and will be overwritten")
+		}
+		if *yes {
+			accepted = append(accepted, u)
+			continue
+		}
+
+		for _, line := range unifiedDiff(u.diff, 3) {
+			fmt.Println(line)
+		}
+		fmt.Print("Apply this update? [y/N] ")
+		answer, _ := in.ReadString('\n')
+		if strings.EqualFold(strings.TrimSpace(answer), "y") {
+			accepted = append(accepted, u)
+		}
+	}
+	if *check || len(accepted) == 0 {
+		return checkErr
+	}
+
+	applied, err := applyUpdates(ctx, accepted)
+	fmt.Printf("updated %d of %d\n", applied, len(updates))
+	return errors.Join(checkErr, err)
+}
+
+// runUndo implements `agentdl undo [flags]`
+func runUndo(args []string) error {
+	fs := flag.NewFlagSet("undo", flag.ContinueOnError)
+	to := fs.String("to", "all", "where to undo the last install: all (whichever was newest), global, project or a directory")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: agentdl undo [flags]")
+		fmt.Fprintln(fs.Output(), "Restores the files the last install or update replaced and removes the ones it added.")
+		fs.PrintDefaults()
+	}
+
+	rest, err := parseInterleaved(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) > 0 {
+		fs.Usage()
+		return errUsage
+	}
+
+	// Undo whichever location was installed into last
+	var lockFile, newest string
+	for _, f := range lockFiles(*to) {
+		if dir, ok := latestBackup(f); ok && filepath.Base(dir) > filepath.Base(newest) {
+			lockFile, newest = f, dir
+		}
+	}
+	if lockFile == "" {
+		return fmt.Errorf("nothing to undo")
+	}
+
+	backup, err := undoLastInstall(lockFile)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("undid the install of %s: restored %d files in %s\n", backup.CreatedAt.Local().Format("2006-01-02 15:04:05"), len(backup.Files), filepath.Dir(lockFile))
+	return nil
+}
+
+// shortSHA abbreviates a commit for display
+func shortSHA(sha string) string {
+	if sha == "" {
+		return "unknown"
+	}
+	return sha[:min(len(sha), 7)]
+}
+
+// installLocation maps --to onto a directory: the kind's global or project
+// directory, or the given path
+func installLocation(to string, mode searchMode) string {
+	switch to {
+	case "global":
+		return locationPath(locationGlobal, mode)
+	case "project":
+		return locationPath(locationCurrent, mode)
+	}
+	return to
+}
+
+// parseInstallSpec splits owner/repo[:path][@ref]
+func parseInstallSpec(spec string) (repo, p, ref string, err error) {
+	if i := strings.LastIndex(spec, "@"); i >= 0 {
+		spec, ref = spec[:i], spec[i+1:]
+	}
+	repo, p, _ = strings.Cut(spec, ":")
+	if owner, name, ok := strings.Cut(repo, "/"); !ok || owner == "" || name == "" || strings.Contains(name, "/") {
+		return "", "", "", fmt.Errorf("want owner/repo[:path][@ref]")
+	}
+	return repo, strings.Trim(p, "/"), ref, nil
+}
+
+// resolveInstallSpec turns a spec into selections. A path to a file of the kind
+// selects that file; any other path, or none, selects every file of the kind
+// beneath it, defaulting to where the kind usually lives.
+func resolveInstallSpec(ctx context.Context, spec string, kind github.Kind) ([]GlobalSelection, error) {
+	repo, p, ref, err := parseInstallSpec(spec)
+	if err != nil {
+		return nil, err
+	}
+	if p == "" {
+		p = kind.DefaultPath()
+	}
+
+	// Pin every file to the commit the ref points at now
+	commit, err := github.DefaultClient.ResolveCommit(ctx, repo, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	if kind.Matches(p) {
+		paths = []string{p}
+	} else {
+		files, err := github.DefaultClient.ListTree(ctx, repo, p, commit)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			if kind.Matches(f.Path) {
+				paths = append(paths, f.Path)
+			}
+		}
+		if len(paths) == 0 {
+			return nil, fmt.Errorf("no %s found under %s", kind.Noun, p)
+		}
+	}
+
+	selections := make([]GlobalSelection, len(paths))
+	for i, fp := range paths {
+		selections[i] = resultSelection(searchResult{
+			Repo: repo,
+			Path: fp,
+			URL:  blobURL(repo, commit, fp),
+		})
+		selections[i].Ref = ref
+	}
+	return selections, nil
+}
+
+// writeResults prints results as an aligned table, a JSON array or one JSON
+// object per line
+func writeResults(w io.Writer, results []github.Result, format string) error {
+	switch format {
+	case "json":
+		if results == nil {
+			results = []github.Result{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+
+	case "ndjson":
+		enc := json.NewEncoder(w)
+		for _, r := range results {
+			if err := enc.Encode(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "STARS\tREPO\tPATH\tDESCRIPTION")
+	for _, r := range results {
+		p := r.Path
+		if r.MCPServer != "" {
+			p += " → " + r.MCPServer
+		}
+		description := ""
+		if r.Meta != nil {
+			description = truncate(strings.Join(strings.Fields(r.Meta.Description), " "), 60)
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", r.Stars, r.Repo, p, description)
+	}
+	return tw.Flush()
+}
+
+// parseInterleaved parses flags that may come before, between or after the
+// positional arguments, returning the positional ones
+func parseInterleaved(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		rest := fs.Args()
+		// Everything after -- is positional
+		if consumed := len(args) - len(rest); consumed > 0 && args[consumed-1] == "--" {
+			return append(positional, rest...), nil
+		}
+		if len(rest) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
+
+// policyNames lists the --on-conflict values
+func policyNames() string {
+	names := make([]string, len(collisionPolicies))
+	for i, p := range collisionPolicies {
+		names[i] = p.name
+	}
+	return strings.Join(names, ", ")
+}
+
+// kindSlugs lists the --mode values
+func kindSlugs() string {
+	slugs := make([]string, len(github.Kinds))
+	for i, k := range github.Kinds {
+		slugs[i] = k.Slug
+	}
+	return strings.Join(slugs, ", ")
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"reflect"
+	"testing"
+
+	"agent-search/github"
+)
+
+func TestParseInterleaved(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		positional []string
+		limit      int
+		json       bool
+	}{
+		{
+			name:       "flags first",
+			args:       []string{"--limit", "5", "--json", "code", "review"},
+			positional: []string{"code", "review"},
+			limit:      5,
+			json:       true,
+		},
+		{
+			name:       "flags between and after",
+			args:       []string{"code", "--limit=5", "review", "--json"},
+			positional: []string{"code", "review"},
+			limit:      5,
+			json:       true,
+		},
+		{
+			name:       "everything after -- is positional",
+			args:       []string{"code", "--", "--json", "-x"},
+			positional: []string{"code", "--json", "-x"},
+			limit:      30,
+		},
+		{
+			name:  "no positional arguments",
+			args:  []string{"--json"},
+			limit: 30,
+			json:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("search", flag.ContinueOnError)
+			limit := fs.Int("limit", 30, "")
+			asJSON := fs.Bool("json", false, "")
+
+			positional, err := parseInterleaved(fs, tt.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(positional, tt.positional) {
+				t.Errorf("positional = %q, want %q", positional, tt.positional)
+			}
+			if *limit != tt.limit || *asJSON != tt.json {
+				t.Errorf("limit, json = %d, %v; want %d, %v", *limit, *asJSON, tt.limit, tt.json)
+			}
+		})
+	}
+}
+
+func TestParseInterleavedRejectsUnknownFlags(t *testing.T) {
+	fs := flag.NewFlagSet("search", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	if _, err := parseInterleaved(fs, []string{"code", "--nope"}); err == nil {
+		t.Error("accepted an unknown flag, want an error")
+	}
+}
+
+func TestParseInstallSpec(t *testing.T) {
+	tests := []struct {
+		spec    string
+		repo    string
+		path    string
+		ref     string
+		wantErr bool
+	}{
+		{spec: "acme/tools", repo: "acme/tools"},
+		{spec: "acme/tools:.claude/agents/reviewer.md", repo: "acme/tools", path: ".claude/agents/reviewer.md"},
+		{spec: "acme/tools:/.claude/agents/", repo: "acme/tools", path: ".claude/agents"},
+		{spec: "acme/tools@v1.2", repo: "acme/tools", ref: "v1.2"},
+		{spec: "acme/tools:agents@feature/x", repo: "acme/tools", path: "agents", ref: "feature/x"},
+		{spec: "acme", wantErr: true},
+		{spec: "/tools", wantErr: true},
+		{spec: "acme/", wantErr: true},
+		{spec: "acme/tools/extra", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		repo, p, ref, err := parseInstallSpec(tt.spec)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseInstallSpec(%q) error = %v, want error %v", tt.spec, err, tt.wantErr)
+			continue
+		}
+		if repo != tt.repo || p != tt.path || ref != tt.ref {
+			t.Errorf("parseInstallSpec(%q) = %q, %q, %q; want %q, %q, %q", tt.spec, repo, p, ref, tt.repo, tt.path, tt.ref)
+		}
+	}
+}
+
+func TestSearchRejectsNegativeLimit(t *testing.T) {
+	if code := runSubcommand([]string{"search", "reviewer", "--limit", "-1"}); code != 2 {
+		t.Errorf("exit code = %d, want 2", code)
+	}
+}
+
+func TestUpdateAcceptsNoCache(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Chdir(t.TempDir())
+	t.Cleanup(func() { github.DefaultCache.Disabled = false })
+
+	if code := runSubcommand([]string{"update", "--check", "--no-cache"}); code != 0 {
+		t.Errorf("exit code = %d, want 0", code)
+	}
+	if !github.DefaultCache.Disabled {
+		t.Error("--no-cache left the cache enabled")
+	}
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"agent-search/github"
+)
+
+// ============================
+// Install Collisions
+// ============================
+
+// collisionPolicy decides what happens when a selection would replace a file
+// that is already there, or that another selection also writes
+type collisionPolicy int
+
+const (
+	policySkip      collisionPolicy = iota // Keep what is there; of several selections, install the first
+	policyOverwrite                        // Replace it; of several selections, the last wins
+	policyRename                           // Install as <name>-<owner>-<repo>
+	policyNamespace                        // Install into an <owner>/ subfolder
+)
+
+// collisionPolicies names the policies, in the order the resolution screen
+// lists them, for --on-conflict
+var collisionPolicies = []struct {
+	policy collisionPolicy
+	name   string
+	help   string
+}{
+	{policySkip, "skip", "Skip them and keep what is there"},
+	{policyOverwrite, "overwrite", "Overwrite what is there"},
+	{policyRename, "rename", "Rename them with a repo suffix, e.g. reviewer-owner-repo.md"},
+	{policyNamespace, "namespace", "Put them in <owner>/ subfolders"},
+}
+
+// parseCollisionPolicy looks up a policy by name
+func parseCollisionPolicy(name string) (collisionPolicy, bool) {
+	for _, p := range collisionPolicies {
+		if p.name == name {
+			return p.policy, true
+		}
+	}
+	return 0, false
+}
+
+// collision is a selection whose install target is taken
+type collision struct {
+	index    int    // Into the selections
+	target   string // File or directory it would be installed to
+	existing bool   // Something not installed from this source is already there
+	others   []int  // Other selections installing to the same target
+}
+
+// describe says what a selection collides with, naming its target relative
+// to the install location
+func (c collision) describe(selections []GlobalSelection, location string) string {
+	target := c.target
+	if rel, err := filepath.Rel(location, target); err == nil {
+		target = rel
+	}
+
+	var with []string
+	if c.existing {
+		with = append(with, "an existing file")
+	}
+	for _, i := range c.others {
+		with = append(with, selections[i].Repo)
+	}
+	return fmt.Sprintf("%s/%s → %s (taken by %s)", selections[c.index].Repo, selections[c.index].Path, target, strings.Join(with, ", "))
+}
+
+// findCollisions finds selections that would replace an existing file or each
+// other. Merged kinds never collide, and a file the lockfile says came from the
+// same source is a reinstall rather than a collision.
+func findCollisions(selections []GlobalSelection, location string, kind github.Kind) []collision {
+	lock, err := readLockFile(lockPath(location))
+	if err != nil {
+		lock = &LockFile{}
+	}
+
+	byTarget := make(map[string][]int)
+	var targets []string
+	for i, sel := range selections {
+		if !replacesTarget(kind.Install, sel.Path) {
+			continue
+		}
+		target := installTarget(kind.Install, location, sel)
+		if _, ok := byTarget[target]; !ok {
+			targets = append(targets, target)
+		}
+		byTarget[target] = append(byTarget[target], i)
+	}
+
+	var collisions []collision
+	for _, target := range targets {
+		_, err := os.Stat(target)
+		onDisk := err == nil
+
+		for _, i := range byTarget[target] {
+			c := collision{
+				index:    i,
+				target:   target,
+				existing: onDisk && !installedFrom(lock, lockTarget(lockPath(location), target), selections[i]),
+			}
+			for _, j := range byTarget[target] {
+				if j != i {
+					c.others = append(c.others, j)
+				}
+			}
+			if c.existing || len(c.others) > 0 {
+				collisions = append(collisions, c)
+			}
+		}
+	}
+	return collisions
+}
+
+// installedFrom reports whether the lockfile says target was installed from
+// the same file as sel
+func installedFrom(lock *LockFile, target string, sel GlobalSelection) bool {
+	for _, e := range lock.Entries {
+		if e.Target == target {
+			return e.Repo == sel.Repo && e.Path == sel.Path
+		}
+	}
+	return false
+}
+
+// resolveCollisions applies a policy to the colliding selections, returning the
+// selections to install and the ones skipped. Renamed and namespaced targets
+// are checked again and numbered until each one is free.
+func resolveCollisions(selections []GlobalSelection, collisions []collision, policy collisionPolicy, location string, kind github.Kind) (install, skipped []GlobalSelection) {
+	colliding := make(map[int]collision)
+	for _, c := range collisions {
+		colliding[c.index] = c
+	}
+
+	var moved []int // Into install
+	for i, sel := range selections {
+		c, ok := colliding[i]
+		if !ok {
+			install = append(install, sel)
+			continue
+		}
+
+		switch policy {
+		case policySkip:
+			// Something already there wins; otherwise the first selection does
+			if c.existing || (len(c.others) > 0 && c.others[0] < i) {
+				skipped = append(skipped, sel)
+				continue
+			}
+		case policyRename:
+			sel.FileName = renamedWithRepo(sel.FileName, sel.Repo)
+			moved = append(moved, len(install))
+		case policyNamespace:
+			owner, _, _ := strings.Cut(sel.Repo, "/")
+			sel.FileName = owner + "/" + sel.FileName
+			moved = append(moved, len(install))
+		}
+		install = append(install, sel)
+	}
+	claimTargets(install, moved, location, kind)
+	return install, skipped
+}
+
+// claimTargets numbers the moved selections until none of them lands on
+// something already there or on another selection's target, in order: a
+// second reviewer-acme-tools.md becomes reviewer-acme-tools-2.md
+func claimTargets(install []GlobalSelection, moved []int, location string, kind github.Kind) {
+	isMoved := make(map[int]bool)
+	for _, i := range moved {
+		isMoved[i] = true
+	}
+	var claimed []GlobalSelection
+	for i, sel := range install {
+		if !isMoved[i] {
+			claimed = append(claimed, sel)
+		}
+	}
+
+	for _, i := range moved {
+		name := install[i].FileName
+		for n := 2; collides(claimed, install[i], location, kind); n++ {
+			install[i].FileName = numberedName(name, n)
+		}
+		claimed = append(claimed, install[i])
+	}
+}
+
+// collides reports whether installing sel alongside the claimed selections
+// would replace something
+func collides(claimed []GlobalSelection, sel GlobalSelection, location string, kind github.Kind) bool {
+	candidates := append(claimed[:len(claimed):len(claimed)], sel)
+	for _, c := range findCollisions(candidates, location, kind) {
+		if c.index == len(claimed) {
+			return true
+		}
+	}
+	return false
+}
+
+// renamedWithRepo suffixes a file or directory name with its repository, keeping
+// the extension: reviewer.md from acme/tools becomes reviewer-acme-tools.md
+func renamedWithRepo(name, repo string) string {
+	dir, base := path.Split(name)
+	ext := path.Ext(base)
+	stem := strings.TrimSuffix(base, ext)
+	return dir + stem + "-" + strings.ReplaceAll(repo, "/", "-") + ext
+}
+
+// numberedName suffixes a file or directory name with n, keeping the
+// extension: reviewer.md becomes reviewer-2.md
+func numberedName(name string, n int) string {
+	ext := path.Ext(path.Base(name))
+	return fmt.Sprintf("%s-%d%s", strings.TrimSuffix(name, ext), n, ext)
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"agent-search/github"
+)
+
+func TestResolveCollisions(t *testing.T) {
+	agent := func(repo, name string) GlobalSelection {
+		return GlobalSelection{Repo: repo, Path: ".claude/agents/" + name, FileName: name}
+	}
+
+	tests := []struct {
+		name        string
+		existing    []string // Files already in the location
+		selections  []GlobalSelection
+		policy      collisionPolicy
+		wantNames   []string
+		wantSkipped int
+	}{
+		{
+			name:        "skip keeps the first",
+			selections:  []GlobalSelection{agent("acme/tools", "reviewer.md"), agent("other/x", "reviewer.md")},
+			policy:      policySkip,
+			wantNames:   []string{"reviewer.md"},
+			wantSkipped: 1,
+		},
+		{
+			name:       "rename suffixes the repo",
+			selections: []GlobalSelection{agent("acme/tools", "reviewer.md"), agent("other/x", "reviewer.md")},
+			policy:     policyRename,
+			wantNames:  []string{"reviewer-acme-tools.md", "reviewer-other-x.md"},
+		},
+		{
+			name:       "rename numbers a name already on disk",
+			existing:   []string{"reviewer.md", "reviewer-acme-tools.md", "reviewer-acme-tools-2.md"},
+			selections: []GlobalSelection{agent("acme/tools", "reviewer.md")},
+			policy:     policyRename,
+			wantNames:  []string{"reviewer-acme-tools-3.md"},
+		},
+		{
+			name: "rename numbers a name another selection installs",
+			selections: []GlobalSelection{
+				agent("acme/tools", "reviewer.md"),
+				agent("other/x", "reviewer.md"),
+				agent("third/y", "reviewer-acme-tools.md"),
+			},
+			policy:    policyRename,
+			wantNames: []string{"reviewer-acme-tools-2.md", "reviewer-other-x.md", "reviewer-acme-tools.md"},
+		},
+		{
+			name:       "namespace numbers selections from the same owner",
+			selections: []GlobalSelection{agent("acme/tools", "reviewer.md"), agent("acme/extra", "reviewer.md")},
+			policy:     policyNamespace,
+			wantNames:  []string{"acme/reviewer.md", "acme/reviewer-2.md"},
+		},
+	}
+
+	kind := github.KindFor(github.ModeAgents)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			location := t.TempDir()
+			for _, name := range tt.existing {
+				if err := os.WriteFile(filepath.Join(location, name), nil, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			collisions := findCollisions(tt.selections, location, kind)
+			install, skipped := resolveCollisions(tt.selections, collisions, tt.policy, location, kind)
+			var names []string
+			for _, sel := range install {
+				names = append(names, sel.FileName)
+			}
+			if !reflect.DeepEqual(names, tt.wantNames) {
+				t.Errorf("installed %v, want %v", names, tt.wantNames)
+			}
+			if len(skipped) != tt.wantSkipped {
+				t.Errorf("skipped %d, want %d", len(skipped), tt.wantSkipped)
+			}
+			if tt.policy != policySkip {
+				if left := findCollisions(install, location, kind); len(left) > 0 {
+					t.Errorf("still colliding: %v", left)
+				}
+			}
+		})
+	}
+}
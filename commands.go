@@ -1,12 +1,17 @@
 package main
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
-	"io"
-	"net/http"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
 	"agent-search/github"
 	tea "github.com/charmbracelet/bubbletea"
@@ -16,45 +21,54 @@ import (
 // Commands (Async Operations)
 // ============================
 
-func searchGitHub(query string, mode string, searchMode searchMode) tea.Cmd {
+func searchGitHub(ctx context.Context, id int, query string, mode string, searchMode searchMode) tea.Cmd {
 	return func() tea.Msg {
-		// Convert searchMode to github.SearchMode
-		var githubMode github.SearchMode
-		if searchMode == modeCommands {
-			githubMode = github.ModeCommands
-		} else {
-			githubMode = github.ModeAgents
-		}
-
 		opts := github.SearchOptions{
 			MatchMode:  mode,
-			SearchMode: githubMode,
+			SearchMode: searchMode,
 			Limit:      200,
 		}
 
-		githubResults := github.Search(query, opts)
+		return waitForSearchUpdate(id, github.Stream(ctx, query, opts))()
+	}
+}
+
+// waitForSearchUpdate delivers the next batch from a streaming search
+func waitForSearchUpdate(id int, updates <-chan github.Update) tea.Cmd {
+	return func() tea.Msg {
+		update, ok := <-updates
+		if !ok {
+			return searchDoneMsg{id: id}
+		}
 
 		// Convert to our internal type
-		results := make([]searchResult, len(githubResults))
-		for i, r := range githubResults {
+		results := make([]searchResult, len(update.Results))
+		for i, r := range update.Results {
 			results[i] = searchResult(r)
 		}
 
-		return searchResultsMsg{results: results}
+		return searchResultsMsg{id: id, results: results, repoInfo: update.RepoInfo, metadata: update.Metadata, updates: updates, err: update.Err}
+	}
+}
+
+// fetchRateLimits refreshes the API budgets shown in the status bar
+func fetchRateLimits() tea.Cmd {
+	return func() tea.Msg {
+		limits, _ := github.RefreshRateLimits(context.Background())
+		return rateLimitMsg{limits: limits}
 	}
 }
 
 func fetchFileContent(url string) tea.Cmd {
 	return func() tea.Msg {
-		// Convert to raw URL
-		rawURL := strings.Replace(url, "/blob/", "/raw/", 1)
-		
-		content, err := downloadFile(rawURL)
+		// Previews go through the cache so reopening a file is instant
+		content, err := github.DefaultClient.Download(context.Background(), url)
 		if err != nil {
 			return fileContentMsg{err: err}
 		}
 		
 		// Limit preview to first 100 lines for performance
+		full := content
 		lines := strings.Split(content, "\n")
 		if len(lines) > 100 {
 			lines = lines[:100]
@@ -62,54 +76,588 @@ func fetchFileContent(url string) tea.Cmd {
 		}
 		content = strings.Join(lines, "\n")
 		
-		return fileContentMsg{content: content, err: err}
+		return fileContentMsg{content: content, full: full, err: err}
 	}
 }
 
-func downloadSelectedFiles(results []searchResult, location string, selections *SelectionManager) tea.Cmd {
+// fetchSections downloads a CLAUDE.md and splits it on its headings
+func fetchSections(url string) tea.Cmd {
 	return func() tea.Msg {
-		count := 0
-		
-		// Download all selections from the global selection manager
-		if selections != nil {
-			for _, sel := range selections.GetAll() {
-				url := sel.URL
-				// Convert GitHub blob URL to raw URL
-				url = strings.Replace(url, "github.com", "raw.githubusercontent.com", 1)
-				url = strings.Replace(url, "/blob/", "/", 1)
-				
-				content, err := downloadFile(url)
-				if err != nil {
-					continue
-				}
-				
-				destPath := filepath.Join(location, sel.FileName)
-				os.MkdirAll(filepath.Dir(destPath), 0755)
-				os.WriteFile(destPath, []byte(content), 0644)
-				count++
+		content, err := github.DefaultClient.Download(context.Background(), url)
+		if err != nil {
+			return sectionsMsg{err: err}
+		}
+		return sectionsMsg{sections: github.SplitSections(content)}
+	}
+}
+
+// checkForUpdates compares every installed file with its upstream
+func checkForUpdates() tea.Cmd {
+	return func() tea.Msg {
+		updates, err := checkUpdates(context.Background(), lockFiles("all"))
+		return updatesMsg{updates: updates, err: err}
+	}
+}
+
+// applyAcceptedUpdates installs the accepted updates
+func applyAcceptedUpdates(updates []pendingUpdate) tea.Cmd {
+	return func() tea.Msg {
+		count, err := applyUpdates(context.Background(), updates)
+		return updatesAppliedMsg{count: count, err: err}
+	}
+}
+
+// planDownload works out what installing the selections into location would write
+func planDownload(ctx context.Context, id int, selections []GlobalSelection, location string, mode searchMode) tea.Cmd {
+	return func() tea.Msg {
+		plan, results, err := planSelections(ctx, selections, location, mode)
+		var failed []downloadResult
+		for _, r := range results {
+			if r.status == downloadFailed {
+				failed = append(failed, r)
 			}
 		}
-		
-		return downloadCompleteMsg{count: count}
+		return planMsg{id: id, plan: plan, results: failed, err: err}
+	}
+}
+
+// startDownloads installs the selections into location in the background,
+// streaming each file's progress. The skipped selections were left out by the
+// collision policy and are only reported.
+func startDownloads(ctx context.Context, install, skipped []GlobalSelection, location string, mode searchMode, retry bool) tea.Cmd {
+	return func() tea.Msg {
+		// Room for every message, so a download nobody is watching never blocks
+		updates := make(chan tea.Msg, 2*len(install)+1)
+		go func() {
+			results, err := installSelections(ctx, install, location, mode, func(e downloadEvent) {
+				updates <- downloadProgressMsg{event: e, updates: updates}
+			})
+			for _, sel := range skipped {
+				results = append(results, downloadResult{sel: sel, status: downloadSkipped, err: errNameTaken})
+			}
+			updates <- downloadCompleteMsg{results: results, err: err, retry: retry}
+		}()
+		return <-updates
+	}
+}
+
+// waitForDownloadUpdate delivers the next message from a running download
+func waitForDownloadUpdate(updates <-chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		return <-updates
+	}
+}
+
+// downloadStatus is where one selection is in a download
+type downloadStatus int
+
+const (
+	downloadPending downloadStatus = iota
+	downloadRunning
+	downloadSaved
+	downloadSkipped
+	downloadFailed
+)
+
+var (
+	// errNameTaken is why a selection skipped by the collision policy was not installed
+	errNameTaken = errors.New("the name was taken")
+
+	// errDownloadCancelled is why selections were not installed after an abort
+	errDownloadCancelled = errors.New("download cancelled")
+)
+
+// downloadResult is the outcome of installing one selection
+type downloadResult struct {
+	sel    GlobalSelection
+	status downloadStatus
+	dest   string // Where it was installed, when saved
+	err    error  // Why it was skipped or failed
+}
+
+// downloadEvent reports that a selection started downloading or finished
+type downloadEvent struct {
+	index  int // Into the selections being installed
+	result downloadResult
+}
+
+// installSelections downloads the selections, five at a time, and installs each
+// into location using the mode's install strategy, returning what happened to
+// each. Each install is recorded in the location's lockfile; the error reports
+// a lockfile that could not be updated or a batch that was rolled back.
+// progress, if set, is called from several goroutines as files start and
+// finish. Cancelling ctx stops the transfers, and nothing is installed after
+// that.
+func installSelections(ctx context.Context, selections []GlobalSelection, location string, mode searchMode, progress func(downloadEvent)) ([]downloadResult, error) {
+	results, stage, staged, err := stageSelections(ctx, selections, location, mode, progress)
+	if err == nil && len(staged) > 0 {
+		err = stage.commit(lockPath(location))
+	}
+	for _, i := range staged {
+		if err != nil {
+			// Nothing was installed, so none of the batch was saved
+			results[i] = downloadResult{sel: results[i].sel, status: downloadFailed, err: fmt.Errorf("rolled back: %w", err)}
+		}
+		if progress != nil {
+			progress(downloadEvent{index: i, result: results[i]})
+		}
 	}
+	return results, err
 }
 
-// Helper function to download a file from URL
-func downloadFile(url string) (string, error) {
-	resp, err := http.Get(url)
+// stageSelections downloads the selections, five at a time, and stages each for
+// installing into location, along with the lockfile entries recording them.
+// Selections are staged in order, so merges and overwrites land as they would
+// one by one. It returns what happened to each selection and which were
+// staged; those are reported to progress once the caller knows their fate.
+func stageSelections(ctx context.Context, selections []GlobalSelection, location string, mode searchMode, progress func(downloadEvent)) ([]downloadResult, *stagedInstall, []int, error) {
+	kind := github.KindFor(mode)
+	results := make([]downloadResult, len(selections))
+	report := func(i int, r downloadResult) {
+		if progress != nil {
+			progress(downloadEvent{index: i, result: r})
+		}
+	}
+
+	lockFile := lockPath(location)
+	lock, err := readLockFile(lockFile)
 	if err != nil {
-		return "", fmt.Errorf("failed to download: %w", err)
+		for i, sel := range selections {
+			results[i] = downloadResult{sel: sel, status: downloadFailed, err: err}
+			report(i, results[i])
+		}
+		return results, nil, nil, err
+	}
+
+	// A ref resolves to the same commit for every file in a repository. Each
+	// ref is looked up once; the lock only guards the map, so different
+	// repositories resolve in parallel.
+	type resolved struct {
+		once   sync.Once
+		commit string
+		err    error
 	}
-	defer resp.Body.Close()
+	commits := make(map[string]*resolved)
+	var mu sync.Mutex
+	resolve := func(repo, ref string) (string, error) {
+		key := repo + "@" + ref
+		mu.Lock()
+		r, ok := commits[key]
+		if !ok {
+			r = &resolved{}
+			commits[key] = r
+		}
+		mu.Unlock()
 
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
+		r.once.Do(func() {
+			r.commit, r.err = github.DefaultClient.ResolveCommit(ctx, repo, ref)
+		})
+		return r.commit, r.err
 	}
 
-	content, err := io.ReadAll(resp.Body)
+	// Download in parallel; each selection's outcome waits in its own channel
+	type fetched struct {
+		commit string
+		files  []fetchedFile
+		err    error
+	}
+	fetches := make([]chan fetched, len(selections))
+	sem := make(chan struct{}, 5)
+	for i, sel := range selections {
+		fetches[i] = make(chan fetched, 1)
+		go func(i int, sel GlobalSelection) {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				fetches[i] <- fetched{err: ctx.Err()}
+				return
+			}
+			defer func() { <-sem }()
+			report(i, downloadResult{sel: sel, status: downloadRunning})
+
+			// Download from the exact commit the ref points at now, so every file
+			// comes from the same tree and the lockfile says which one
+			ref := github.RefFromURL(sel.URL)
+			commit, err := resolve(sel.Repo, ref)
+			if err != nil {
+				fetches[i] <- fetched{err: fmt.Errorf("resolving %s: %w", refName(ref), err)}
+				return
+			}
+			files, err := fetchSelection(ctx, sel.Repo, github.PinURL(sel.URL, commit), sel.Path, sel.Dir)
+			fetches[i] <- fetched{commit: commit, files: files, err: err}
+		}(i, sel)
+	}
+
+	stage := &stagedInstall{}
+	var staged []int
+	for i, sel := range selections {
+		f := <-fetches[i]
+		if ctx.Err() != nil {
+			f.err = errDownloadCancelled
+		}
+		if f.err != nil {
+			results[i] = downloadResult{sel: sel, status: downloadFailed, err: f.err}
+			report(i, results[i])
+			continue
+		}
+
+		pinned := sel
+		pinned.URL = github.PinURL(sel.URL, f.commit)
+		mark := stage.mark()
+		dest, hash, err := writeSelection(stage, pinned, location, kind, f.files)
+		if err != nil {
+			stage.reset(mark)
+			results[i] = downloadResult{sel: sel, status: downloadFailed, err: err}
+			report(i, results[i])
+			continue
+		}
+		results[i] = downloadResult{sel: sel, status: downloadSaved, dest: dest}
+		staged = append(staged, i)
+
+		ref := github.RefFromURL(sel.URL)
+		entry := LockEntry{
+			Kind:        kind.Slug,
+			Repo:        sel.Repo,
+			Path:        sel.Path,
+			Server:      sel.Server,
+			Sections:    sel.Sections,
+			Ref:         sel.Ref,
+			Commit:      f.commit,
+			SHA256:      hash,
+			Target:      lockTarget(lockFile, dest),
+			InstalledAt: time.Now().UTC(),
+		}
+		// A commit in the URL pins the content, not the ref to follow
+		if entry.Ref == "" && !github.IsCommitSHA(ref) {
+			entry.Ref = ref
+		}
+		lock.record(entry)
+	}
+
+	if len(staged) == 0 {
+		return results, stage, nil, nil
+	}
+	return results, stage, staged, lock.stage(stage, lockFile)
+}
+
+// refName describes a ref for messages
+func refName(ref string) string {
+	if ref == "" {
+		return "the default branch"
+	}
+	return ref
+}
+
+// countResults counts the results with a status
+func countResults(results []downloadResult, status downloadStatus) int {
+	n := 0
+	for _, r := range results {
+		if r.status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// fetchedFile is one downloaded file; rel is its path within a skill's
+// directory, and empty for single files
+type fetchedFile struct {
+	rel     string
+	content string
+}
+
+// fetchSelection downloads a selection's file, or every file in its skill
+// directory, without installing anything
+func fetchSelection(ctx context.Context, repo, url, p string, dir bool) ([]fetchedFile, error) {
+	if !dir {
+		content, err := github.DefaultClient.Download(ctx, url)
+		if err != nil {
+			return nil, err
+		}
+		return []fetchedFile{{content: content}}, nil
+	}
+
+	// Skills are whole folders: SKILL.md plus its resource files
+	skillDir := path.Dir(p)
+	tree, err := github.DefaultClient.ListTree(ctx, repo, skillDir, github.RefFromURL(url))
 	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
+		return nil, err
+	}
+	_, ref, _, _ := github.ParseBlobURL(url)
+	files := make([]fetchedFile, 0, len(tree))
+	for _, f := range tree {
+		content, err := github.DefaultClient.Download(ctx, blobURL(repo, ref, f.Path))
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, fetchedFile{rel: strings.TrimPrefix(f.Path, skillDir+"/"), content: content})
 	}
+	return files, nil
+}
+
+// writeSelection stages a selection's downloaded files for installing into
+// location, returning where they go and a hash of what was downloaded
+func writeSelection(stage *stagedInstall, sel GlobalSelection, location string, kind github.Kind, files []fetchedFile) (dest, hash string, err error) {
+	if sel.Dir {
+		dest = installTarget(kind.Install, location, sel)
+		hash, err = writeSkill(stage, dest, files)
+		return dest, hash, err
+	}
+
+	dest, err = install(stage, kind.Install, location, files[0].content, sel)
+	return dest, contentHash(files[0].content), err
+}
+
+// installSelection downloads one selection and stages it for installing into
+// location, returning where it goes and a hash of what was downloaded
+func installSelection(ctx context.Context, stage *stagedInstall, sel GlobalSelection, location string, kind github.Kind) (dest, hash string, err error) {
+	files, err := fetchSelection(ctx, sel.Repo, sel.URL, sel.Path, sel.Dir)
+	if err != nil {
+		return "", "", err
+	}
+	return writeSelection(stage, sel, location, kind, files)
+}
+
+// install stages a downloaded file using the kind's install strategy, returning
+// the file it will be written or merged into
+func install(stage *stagedInstall, strategy github.InstallStrategy, location, content string, sel GlobalSelection) (string, error) {
+	dest := installTarget(strategy, location, sel)
+	switch strategy {
+	case github.InstallMCP:
+		// MCP servers merge into the target's server config
+		return dest, installMCPServer(stage, dest, content, sel.Server)
+	case github.InstallAppend:
+		// CLAUDE.md is appended to; only an earlier import of the same file is replaced
+		return dest, installMemory(stage, dest, content, sel)
+	case github.InstallHooks:
+		// Hook settings merge into the existing settings.json rather than replace it
+		if github.IsSettingsFile(sel.Path) {
+			return dest, installHooks(stage, dest, content, sel.Replaces)
+		}
+		// Hook scripts must stay executable
+		return dest, stage.write(dest, content, 0755)
+	case github.InstallStatusline:
+		return dest, installStatusline(stage, dest, content)
+	}
+	return dest, stage.write(dest, content, 0644)
+}
+
+// installTarget is the file or directory a selection is installed to
+func installTarget(strategy github.InstallStrategy, location string, sel GlobalSelection) string {
+	switch strategy {
+	case github.InstallMCP:
+		return filepath.Join(location, mcpConfigName(location))
+	case github.InstallAppend:
+		return filepath.Join(location, "CLAUDE.md")
+	case github.InstallHooks:
+		if github.IsSettingsFile(sel.Path) {
+			return filepath.Join(location, "settings.json")
+		}
+		// Hook scripts keep their layout under hooks/
+		return filepath.Join(location, "hooks", filepath.FromSlash(sel.FileName))
+	}
+	return filepath.Join(location, sel.FileName)
+}
+
+// replacesTarget reports whether installing a file of this strategy replaces its
+// target outright, rather than merging into a shared file
+func replacesTarget(strategy github.InstallStrategy, p string) bool {
+	switch strategy {
+	case github.InstallFile, github.InstallSkill, github.InstallStatusline:
+		return true
+	case github.InstallHooks:
+		return !github.IsSettingsFile(p)
+	}
+	return false
+}
+
+// installStatusline writes a statusline script to dest, executable, and points
+// the settings.json beside it at the script
+func installStatusline(stage *stagedInstall, script, content string) error {
+	if err := stage.write(script, content, 0755); err != nil {
+		return err
+	}
+
+	settingsPath := filepath.Join(filepath.Dir(script), "settings.json")
+	existing, err := stage.read(settingsPath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	updated, err := github.SetStatusLine(existing, script)
+	if err != nil {
+		return err
+	}
+	return stage.write(settingsPath, string(updated), 0644)
+}
+
+// installHooks merges the hooks block of a downloaded settings file into the
+// settings file at settingsPath, creating it if needed and keeping every other
+// setting. The hooks of replaces, the version an update supersedes, are removed.
+func installHooks(stage *stagedInstall, settingsPath, content, replaces string) error {
+	hooks, err := github.ParseHooks([]byte(content))
+	if err != nil {
+		return err
+	}
+	if len(hooks) == 0 {
+		return fmt.Errorf("no hooks configured")
+	}
+	var replaced github.Hooks
+	if replaces != "" {
+		if replaced, err = github.ParseHooks([]byte(replaces)); err != nil {
+			return err
+		}
+	}
+
+	existing, err := stage.read(settingsPath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	merged, err := github.MergeHooks(existing, hooks, replaced)
+	if err != nil {
+		return err
+	}
+	return stage.write(settingsPath, string(merged), 0644)
+}
 
-	return string(content), nil
+// installMemory appends the picked sections of a downloaded CLAUDE.md (all of it
+// when none were picked) to the CLAUDE.md at memoryPath, between markers naming
+// the file they came from. An earlier import of the same file is replaced in
+// place, so updates and re-picks do not pile up. Sections present elsewhere in
+// the file are skipped so importing the same text twice adds nothing.
+func installMemory(stage *stagedInstall, memoryPath, content string, sel GlobalSelection) error {
+	existing, err := stage.read(memoryPath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	source := sel.Repo + "/" + sel.Path
+	before, after, found := cutImport(string(existing), source)
+	rest := before + after
+
+	var picked []string
+	for _, section := range github.SplitSections(content) {
+		if len(sel.Sections) > 0 && !containsString(sel.Sections, section.Heading) {
+			continue
+		}
+		if strings.Contains(rest, section.Text) {
+			continue
+		}
+		picked = append(picked, section.Text)
+	}
+
+	var block string
+	if len(picked) > 0 {
+		block = importStart(source) + strings.Join(picked, "\n\n") + "\n" + importEnd(source)
+	}
+
+	var b strings.Builder
+	switch {
+	case found:
+		b.WriteString(before)
+		b.WriteString(block)
+		b.WriteString(after)
+	case block == "":
+		return nil
+	default:
+		b.WriteString(before)
+		if len(before) > 0 {
+			b.WriteString("\n")
+			if !strings.HasSuffix(before, "\n") {
+				b.WriteString("\n")
+			}
+		}
+		b.WriteString(block)
+	}
+	return stage.write(memoryPath, b.String(), 0644)
+}
+
+// importStart and importEnd mark the sections imported from source in a CLAUDE.md
+func importStart(source string) string {
+	return fmt.Sprintf("<!-- Imported from %s -->\n", source)
+}
+
+func importEnd(source string) string {
+	return fmt.Sprintf("<!-- End of import from %s -->\n", source)
+}
+
+// cutImport splits a CLAUDE.md around the block imported from source, reporting
+// whether there was one. A block without an end marker runs to the next import
+// or the end of the file.
+func cutImport(content, source string) (before, after string, found bool) {
+	start := strings.Index(content, importStart(source))
+	if start < 0 {
+		return content, "", false
+	}
+	rest := content[start+len(importStart(source)):]
+	if end := strings.Index(rest, importEnd(source)); end >= 0 {
+		return content[:start], rest[end+len(importEnd(source)):], true
+	}
+	if next := strings.Index(rest, "<!-- Imported from "); next >= 0 {
+		return content[:start], rest[next:], true
+	}
+	return content[:start], "", true
+}
+
+func containsString(list []string, v string) bool {
+	for _, x := range list {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+// installMCPServer merges one server from a downloaded config, or all of them
+// when server is empty, into the MCP config at configPath
+func installMCPServer(stage *stagedInstall, configPath, content, server string) error {
+	servers, err := github.ParseMCPServers([]byte(content))
+	if err != nil {
+		return err
+	}
+	if server != "" {
+		def, ok := servers[server]
+		if !ok {
+			return fmt.Errorf("server %q not found", server)
+		}
+		servers = github.MCPServers{server: def}
+	}
+	if len(servers) == 0 {
+		return fmt.Errorf("no MCP servers configured")
+	}
+
+	existing, err := stage.read(configPath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	merged, err := github.MergeMCPServers(existing, servers)
+	if err != nil {
+		return err
+	}
+	return stage.write(configPath, string(merged), 0644)
+}
+
+// mcpConfigName is the file MCP servers are installed into at location:
+// ~/.claude.json for the home directory, .mcp.json for a project
+func mcpConfigName(location string) string {
+	home, _ := os.UserHomeDir()
+	if abs, err := filepath.Abs(location); err == nil && abs == home {
+		return ".claude.json"
+	}
+	return ".mcp.json"
+}
+
+// writeSkill stages a skill's files into dest, returning a hash over their
+// paths and contents
+func writeSkill(stage *stagedInstall, dest string, files []fetchedFile) (string, error) {
+	hash := sha256.New()
+	for _, f := range files {
+		destPath := filepath.Join(dest, filepath.FromSlash(f.rel))
+		if err := stage.write(destPath, f.content, 0644); err != nil {
+			return "", err
+		}
+		fmt.Fprintf(hash, "%s\x00%s\x00", f.rel, f.content)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
 }
\ No newline at end of file
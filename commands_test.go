@@ -0,0 +1,89 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestInstallMemory(t *testing.T) {
+	sel := GlobalSelection{Repo: "acme/tools", Path: "CLAUDE.md"}
+	block := func(body string) string {
+		return importStart("acme/tools/CLAUDE.md") + body + "\n" + importEnd("acme/tools/CLAUDE.md")
+	}
+
+	tests := []struct {
+		name     string
+		existing string
+		content  string
+		sections []string
+		want     string
+	}{
+		{
+			name:    "starts a new file",
+			content: "# Style\nTabs.\n",
+			want:    block("# Style\nTabs."),
+		},
+		{
+			name:     "appends after what is there",
+			existing: "# Mine\nKeep me.\n",
+			content:  "# Style\nTabs.\n",
+			want:     "# Mine\nKeep me.\n\n" + block("# Style\nTabs."),
+		},
+		{
+			name:     "picks sections by heading",
+			content:  "# Style\nTabs.\n\n## Tests\nTable tests.\n\n## Commits\nShort.\n",
+			sections: []string{"Tests"},
+			want:     block("## Tests\nTable tests."),
+		},
+		{
+			name:     "replaces an earlier import in place",
+			existing: "# Mine\n\n" + block("# Style\nSpaces.") + "\n# After\nStill here.\n",
+			content:  "# Style\nTabs.\n",
+			want:     "# Mine\n\n" + block("# Style\nTabs.") + "\n# After\nStill here.\n",
+		},
+		{
+			name:     "replaces an earlier import without an end marker up to the next import",
+			existing: importStart("acme/tools/CLAUDE.md") + "# Style\nSpaces.\n\n" + importStart("other/x/CLAUDE.md") + "# Other\n",
+			content:  "# Style\nTabs.\n",
+			want:     block("# Style\nTabs.") + importStart("other/x/CLAUDE.md") + "# Other\n",
+		},
+		{
+			name:     "skips sections already elsewhere in the file",
+			existing: "# Style\nTabs.\n",
+			content:  "# Style\nTabs.\n\n# Tests\nTable tests.\n",
+			want:     "# Style\nTabs.\n\n" + block("# Tests\nTable tests."),
+		},
+		{
+			name:     "leaves the file alone when there is nothing new",
+			existing: "# Style\nTabs.\n",
+			content:  "# Style\nTabs.\n",
+			want:     "# Style\nTabs.\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			memoryPath := filepath.Join(t.TempDir(), "CLAUDE.md")
+			if tt.existing != "" {
+				if err := os.WriteFile(memoryPath, []byte(tt.existing), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var stage stagedInstall
+			sel := sel
+			sel.Sections = tt.sections
+			if err := installMemory(&stage, memoryPath, tt.content, sel); err != nil {
+				t.Fatal(err)
+			}
+			got, err := stage.read(memoryPath)
+			if err != nil && tt.want != "" {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("CLAUDE.md =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,279 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"agent-search/github"
+)
+
+// ============================
+// Line Diffs
+// ============================
+
+// diffOp says what happened to a line
+type diffOp int
+
+const (
+	diffEqual diffOp = iota
+	diffDelete
+	diffInsert
+)
+
+// diffLine is one line of a diff
+type diffLine struct {
+	op   diffOp
+	text string
+}
+
+// maxDiffCells bounds the LCS table; bigger changes diff as a full replacement
+const maxDiffCells = 4_000_000
+
+// diffLines compares two texts line by line using a longest common subsequence
+func diffLines(a, b string) []diffLine {
+	x, y := splitLines(a), splitLines(b)
+
+	// The common prefix and suffix need no table
+	prefix := 0
+	for prefix < len(x) && prefix < len(y) && x[prefix] == y[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(x)-prefix && suffix < len(y)-prefix && x[len(x)-1-suffix] == y[len(y)-1-suffix] {
+		suffix++
+	}
+
+	var out []diffLine
+	for _, l := range x[:prefix] {
+		out = append(out, diffLine{diffEqual, l})
+	}
+	out = append(out, diffMiddle(x[prefix:len(x)-suffix], y[prefix:len(y)-suffix])...)
+	for _, l := range x[len(x)-suffix:] {
+		out = append(out, diffLine{diffEqual, l})
+	}
+	return out
+}
+
+// diffMiddle diffs the differing middle of two texts
+func diffMiddle(x, y []string) []diffLine {
+	var out []diffLine
+	if len(x)*len(y) > maxDiffCells {
+		for _, l := range x {
+			out = append(out, diffLine{diffDelete, l})
+		}
+		for _, l := range y {
+			out = append(out, diffLine{diffInsert, l})
+		}
+		return out
+	}
+
+	// lcs[i][j] is the LCS length of x[i:] and y[j:]
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(x) && j < len(y) {
+		switch {
+		case x[i] == y[j]:
+			out = append(out, diffLine{diffEqual, x[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			out = append(out, diffLine{diffDelete, x[i]})
+			i++
+		default:
+			out = append(out, diffLine{diffInsert, y[j]})
+			j++
+		}
+	}
+	for ; i < len(x); i++ {
+		out = append(out, diffLine{diffDelete, x[i]})
+	}
+	for ; j < len(y); j++ {
+		out = append(out, diffLine{diffInsert, y[j]})
+	}
+	return out
+}
+
+// splitLines splits text into lines, ignoring a final newline
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(strings.ReplaceAll(s, "\r\n", "\n"), "\n"), "\n")
+}
+
+// diffChanged reports whether a diff has any insertions or deletions
+func diffChanged(d []diffLine) bool {
+	for _, l := range d {
+		if l.op != diffEqual {
+			return true
+		}
+	}
+	return false
+}
+
+// diffStat counts inserted and deleted lines
+func diffStat(d []diffLine) (added, removed int) {
+	for _, l := range d {
+		switch l.op {
+		case diffInsert:
+			added++
+		case diffDelete:
+			removed++
+		}
+	}
+	return added, removed
+}
+
+// unifiedDiff renders a diff as unified diff hunks with context lines around
+// each change: "@@" headers, then lines prefixed with " ", "-" or "+"
+func unifiedDiff(d []diffLine, context int) []string {
+	var out []string
+	for start := 0; start < len(d); {
+		// Find the next change
+		first := start
+		for first < len(d) && d[first].op == diffEqual {
+			first++
+		}
+		if first == len(d) {
+			break
+		}
+
+		// Extend the hunk while changes are within two contexts of each other
+		last := first
+		for k := first; k < len(d); k++ {
+			if d[k].op != diffEqual {
+				last = k
+			} else if k-last > 2*context {
+				break
+			}
+		}
+
+		from := max(first-context, start)
+		to := min(last+context+1, len(d))
+
+		// Line numbers of the hunk in the old and new text
+		oldLine, newLine := 1, 1
+		for _, l := range d[:from] {
+			if l.op != diffInsert {
+				oldLine++
+			}
+			if l.op != diffDelete {
+				newLine++
+			}
+		}
+		oldCount, newCount := 0, 0
+		for _, l := range d[from:to] {
+			if l.op != diffInsert {
+				oldCount++
+			}
+			if l.op != diffDelete {
+				newCount++
+			}
+		}
+
+		out = append(out, fmt.Sprintf("@@ -%d,%d +%d,%d @@", oldLine, oldCount, newLine, newCount))
+		for _, l := range d[from:to] {
+			switch l.op {
+			case diffEqual:
+				out = append(out, " "+l.text)
+			case diffDelete:
+				out = append(out, "-"+l.text)
+			case diffInsert:
+				out = append(out, "+"+l.text)
+			}
+		}
+		start = to
+	}
+	return out
+}
+
+// diffRow is one row of a side-by-side diff; a side is empty where the other
+// has a line the first does not
+type diffRow struct {
+	left, right       string
+	hasLeft, hasRight bool
+	changed           bool
+}
+
+// diffRows lays a diff out side by side, pairing each run of deleted lines with
+// the inserted lines that replace it
+func diffRows(d []diffLine) []diffRow {
+	var rows []diffRow
+	for i := 0; i < len(d); {
+		if d[i].op == diffEqual {
+			rows = append(rows, diffRow{left: d[i].text, right: d[i].text, hasLeft: true, hasRight: true})
+			i++
+			continue
+		}
+
+		var deleted, inserted []string
+		for ; i < len(d) && d[i].op != diffEqual; i++ {
+			if d[i].op == diffDelete {
+				deleted = append(deleted, d[i].text)
+			} else {
+				inserted = append(inserted, d[i].text)
+			}
+		}
+		for k := 0; k < max(len(deleted), len(inserted)); k++ {
+			row := diffRow{changed: true}
+			if k < len(deleted) {
+				row.left, row.hasLeft = deleted[k], true
+			}
+			if k < len(inserted) {
+				row.right, row.hasRight = inserted[k], true
+			}
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+// frontmatterChanges describes how the frontmatter fields of two markdown files
+// differ, one line per field: "+ key: value" when added, "- key: value" when
+// removed and "~ key: old → new" when changed, sorted by key
+func frontmatterChanges(a, b string) []string {
+	old, _, _ := github.SplitFrontmatter(a)
+	updated, _, _ := github.SplitFrontmatter(b)
+
+	keys := make(map[string]bool)
+	for k := range old {
+		keys[k] = true
+	}
+	for k := range updated {
+		keys[k] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	var out []string
+	for _, k := range sorted {
+		before, inOld := old[k]
+		after, inNew := updated[k]
+		switch {
+		case !inOld:
+			out = append(out, fmt.Sprintf("+ %s: %s", k, after))
+		case !inNew:
+			out = append(out, fmt.Sprintf("- %s: %s", k, before))
+		case before != after:
+			out = append(out, fmt.Sprintf("~ %s: %s → %s", k, before, after))
+		}
+	}
+	return out
+}
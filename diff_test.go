@@ -0,0 +1,177 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// diffText writes each diff line with a " ", "-" or "+" prefix
+func diffText(d []diffLine) []string {
+	var out []string
+	for _, l := range d {
+		out = append(out, string(" -+"[l.op])+l.text)
+	}
+	return out
+}
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want []string
+	}{
+		{name: "identical", a: "a\nb\n", b: "a\nb\n", want: []string{" a", " b"}},
+		{name: "both empty", a: "", b: ""},
+		{name: "added file", a: "", b: "a\nb", want: []string{"+a", "+b"}},
+		{name: "removed file", a: "a\nb", b: "", want: []string{"-a", "-b"}},
+		{name: "final newline and CRLF are ignored", a: "a\r\nb\r\n", b: "a\nb", want: []string{" a", " b"}},
+		{name: "changed line", a: "a\nb\nc", b: "a\nx\nc", want: []string{" a", "-b", "+x", " c"}},
+		{name: "insert in the middle", a: "a\nc", b: "a\nb\nc", want: []string{" a", "+b", " c"}},
+		{
+			name: "moved line",
+			a:    "a\nb\nc\nd",
+			b:    "b\nc\na\nd",
+			want: []string{"-a", " b", " c", "+a", " d"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := diffLines(tt.a, tt.b)
+			if got := diffText(d); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diff = %q, want %q", got, tt.want)
+			}
+			changed := false
+			for _, l := range tt.want {
+				changed = changed || l[0] != ' '
+			}
+			if diffChanged(d) != changed {
+				t.Errorf("diffChanged = %v, want %v", diffChanged(d), changed)
+			}
+		})
+	}
+}
+
+func TestDiffStat(t *testing.T) {
+	added, removed := diffStat(diffLines("a\nb\nc\n", "a\nx\ny\n"))
+	if added != 2 || removed != 2 {
+		t.Errorf("diffStat = +%d -%d, want +2 -2", added, removed)
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	lines := func(n int, change map[int]string) string {
+		var b strings.Builder
+		for i := 1; i <= n; i++ {
+			if s, ok := change[i]; ok {
+				b.WriteString(s + "\n")
+				continue
+			}
+			b.WriteString("line" + string(rune('a'+i-1)) + "\n")
+		}
+		return b.String()
+	}
+
+	tests := []struct {
+		name    string
+		a, b    string
+		context int
+		want    []string
+	}{
+		{name: "no changes", a: lines(5, nil), b: lines(5, nil), context: 3},
+		{
+			name:    "one change with context",
+			a:       lines(10, nil),
+			b:       lines(10, map[int]string{5: "changed"}),
+			context: 2,
+			want:    []string{"@@ -3,5 +3,5 @@", " linec", " lined", "-linee", "+changed", " linef", " lineg"},
+		},
+		{
+			name:    "nearby changes share a hunk",
+			a:       lines(10, nil),
+			b:       lines(10, map[int]string{3: "x", 6: "y"}),
+			context: 1,
+			want:    []string{"@@ -2,6 +2,6 @@", " lineb", "-linec", "+x", " lined", " linee", "-linef", "+y", " lineg"},
+		},
+		{
+			name:    "distant changes get their own hunks",
+			a:       lines(12, nil),
+			b:       lines(12, map[int]string{2: "x", 11: "y"}),
+			context: 1,
+			want: []string{
+				"@@ -1,3 +1,3 @@", " linea", "-lineb", "+x", " linec",
+				"@@ -10,3 +10,3 @@", " linej", "-linek", "+y", " linel",
+			},
+		},
+		{
+			name:    "insertion into an empty file",
+			a:       "",
+			b:       "a\nb\n",
+			context: 3,
+			want:    []string{"@@ -1,0 +1,2 @@", "+a", "+b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := unifiedDiff(diffLines(tt.a, tt.b), tt.context)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("unifiedDiff =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestDiffRows(t *testing.T) {
+	d := diffLines("a\nb\nc\nd\n", "a\nx\ny\nd\ne\n")
+	want := []diffRow{
+		{left: "a", right: "a", hasLeft: true, hasRight: true},
+		{left: "b", right: "x", hasLeft: true, hasRight: true, changed: true},
+		{left: "c", right: "y", hasLeft: true, hasRight: true, changed: true},
+		{left: "d", right: "d", hasLeft: true, hasRight: true},
+		{right: "e", hasRight: true, changed: true},
+	}
+	if got := diffRows(d); !reflect.DeepEqual(got, want) {
+		t.Errorf("diffRows = %+v, want %+v", got, want)
+	}
+}
+
+func TestFrontmatterChanges(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want []string
+	}{
+		{
+			name: "unchanged",
+			a:    "---\nname: reviewer\n---\nOld body\n",
+			b:    "---\nname: reviewer\n---\nNew body\n",
+		},
+		{
+			name: "added, removed and changed fields, sorted",
+			a:    "---\nname: reviewer\nmodel: sonnet\ncolor: red\n---\n",
+			b:    "---\nname: reviewer\nmodel: opus\ntools: Read, Grep\n---\n",
+			want: []string{"- color: red", "~ model: sonnet → opus", "+ tools: Read, Grep"},
+		},
+		{
+			name: "frontmatter added",
+			a:    "# Reviewer\n",
+			b:    "---\nname: reviewer\n---\n# Reviewer\n",
+			want: []string{"+ name: reviewer"},
+		},
+		{
+			name: "neither has frontmatter",
+			a:    "# Old\n",
+			b:    "# New\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := frontmatterChanges(tt.a, tt.b); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("frontmatterChanges = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
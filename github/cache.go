@@ -0,0 +1,129 @@
+package github
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Cache lifetimes. Entries older than their TTL are revalidated with
+// If-None-Match when they carry an ETag, and refetched otherwise.
+const (
+	searchCacheTTL   = time.Hour
+	repoInfoCacheTTL = 6 * time.Hour
+	contentCacheTTL  = 10 * time.Minute
+)
+
+// Cache is a persistent on-disk store for API responses, search results and file contents
+type Cache struct {
+	Dir      string
+	Disabled bool // Set by --no-cache; every lookup misses and nothing is written
+}
+
+// cacheEntry is the on-disk form of one cached response
+type cacheEntry struct {
+	Key      string    `json:"key"`
+	ETag     string    `json:"etag,omitempty"`
+	Link     string    `json:"link,omitempty"` // Pagination header, replayed on cache hits
+	StoredAt time.Time `json:"storedAt"`
+	Body     []byte    `json:"body"`
+}
+
+// fresh reports whether the entry is younger than ttl
+func (e *cacheEntry) fresh(ttl time.Duration) bool {
+	return time.Since(e.StoredAt) < ttl
+}
+
+// DefaultCache lives under $XDG_CACHE_HOME/agentdl, or ~/.cache/agentdl when unset
+var DefaultCache = &Cache{Dir: defaultCacheDir()}
+
+func defaultCacheDir() string {
+	if dir := os.Getenv("XDG_CACHE_HOME"); dir != "" {
+		return filepath.Join(dir, "agentdl")
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".cache", "agentdl")
+}
+
+// get loads the entry for key, whatever its age
+func (c *Cache) get(key string) (*cacheEntry, bool) {
+	if c == nil || c.Disabled {
+		return nil, false
+	}
+
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Key != key {
+		return nil, false
+	}
+	return &entry, true
+}
+
+// put stores body under key, replacing any previous entry
+func (c *Cache) put(key, etag, link string, body []byte) {
+	if c == nil || c.Disabled {
+		return
+	}
+
+	data, err := json.Marshal(cacheEntry{
+		Key:      key,
+		ETag:     etag,
+		Link:     link,
+		StoredAt: time.Now(),
+		Body:     body,
+	})
+	if err != nil {
+		return
+	}
+
+	// Write to a temp file and rename so readers never see a partial entry
+	if err := os.MkdirAll(c.Dir, 0755); err != nil {
+		return
+	}
+	tmp, err := os.CreateTemp(c.Dir, "entry-*")
+	if err != nil {
+		return
+	}
+	_, werr := tmp.Write(data)
+	cerr := tmp.Close()
+	if werr != nil || cerr != nil {
+		os.Remove(tmp.Name())
+		return
+	}
+	if err := os.Rename(tmp.Name(), c.path(key)); err != nil {
+		os.Remove(tmp.Name())
+	}
+}
+
+// touch marks an entry as freshly validated
+func (c *Cache) touch(entry *cacheEntry) {
+	c.put(entry.Key, entry.ETag, entry.Link, entry.Body)
+}
+
+// getJSON decodes a fresh entry into v
+func (c *Cache) getJSON(key string, ttl time.Duration, v interface{}) bool {
+	entry, ok := c.get(key)
+	if !ok || !entry.fresh(ttl) {
+		return false
+	}
+	return json.Unmarshal(entry.Body, v) == nil
+}
+
+// putJSON encodes v and stores it under key
+func (c *Cache) putJSON(key string, v interface{}) {
+	if data, err := json.Marshal(v); err == nil {
+		c.put(key, "", "", data)
+	}
+}
+
+// path maps a key to its file; keys are hashed since they contain slashes and spaces
+func (c *Cache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.Dir, hex.EncodeToString(sum[:])+".json")
+}
@@ -0,0 +1,192 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testCache returns a cache under a temporary XDG_CACHE_HOME
+func testCache(t *testing.T) *Cache {
+	t.Helper()
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	return &Cache{Dir: defaultCacheDir()}
+}
+
+// age rewrites key's entry as if it had been stored d ago
+func age(t *testing.T, c *Cache, key string, d time.Duration) {
+	t.Helper()
+	entry, ok := c.get(key)
+	if !ok {
+		t.Fatalf("no entry for %q", key)
+	}
+	entry.StoredAt = time.Now().Add(-d)
+	data, err := json.Marshal(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(c.path(key), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCacheDir(t *testing.T) {
+	xdg := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", xdg)
+	if got, want := defaultCacheDir(), filepath.Join(xdg, "agentdl"); got != want {
+		t.Errorf("with XDG_CACHE_HOME = %q, want %q", got, want)
+	}
+
+	home := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", "")
+	t.Setenv("HOME", home)
+	if got, want := defaultCacheDir(), filepath.Join(home, ".cache", "agentdl"); got != want {
+		t.Errorf("without XDG_CACHE_HOME = %q, want %q", got, want)
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	c := testCache(t)
+	key := "search http https://api.github.com 0 all 100 reviewer"
+	c.putJSON(key, []Result{{Repo: "acme/tools", Path: ".claude/agents/reviewer.md"}})
+
+	var results []Result
+	if !c.getJSON(key, searchCacheTTL, &results) || len(results) != 1 {
+		t.Fatalf("fresh entry missed, got %+v", results)
+	}
+
+	age(t, c, key, searchCacheTTL+time.Minute)
+	results = nil
+	if c.getJSON(key, searchCacheTTL, &results) {
+		t.Errorf("expired entry served: %+v", results)
+	}
+	// The expired entry is still there to revalidate
+	if _, ok := c.get(key); !ok {
+		t.Error("expired entry was dropped")
+	}
+}
+
+func TestCacheCorruptEntry(t *testing.T) {
+	c := testCache(t)
+	c.put("good", "", "", []byte("body"))
+
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"not JSON", "{truncated"},
+		{"empty file", ""},
+		// Stored under another key's hash, however that happened
+		{"wrong key", `{"key": "other", "storedAt": "2026-01-01T00:00:00Z", "body": "Ym9keQ=="}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := os.WriteFile(c.path("good"), []byte(tt.data), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if entry, ok := c.get("good"); ok {
+				t.Errorf("served %+v from a corrupt entry", entry)
+			}
+			var v interface{}
+			if c.getJSON("good", time.Hour, &v) {
+				t.Error("getJSON served a corrupt entry")
+			}
+		})
+	}
+
+	// A fresh put replaces the corrupt file
+	c.put("good", "", "", []byte("again"))
+	if entry, ok := c.get("good"); !ok || string(entry.Body) != "again" {
+		t.Errorf("after rewrite got %+v, %v", entry, ok)
+	}
+}
+
+func TestCacheDisabled(t *testing.T) {
+	c := testCache(t)
+	c.Disabled = true
+	c.put("key", "", "", []byte("body"))
+	if _, ok := c.get("key"); ok {
+		t.Error("disabled cache served an entry")
+	}
+	if _, err := os.Stat(c.Dir); !os.IsNotExist(err) {
+		t.Errorf("disabled cache wrote to %s", c.Dir)
+	}
+}
+
+func TestCacheETagRevalidation(t *testing.T) {
+	var requests, notModified atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte("# Reviewer\n"))
+	}))
+	defer srv.Close()
+
+	c := testCache(t)
+	client := &Client{BaseURL: srv.URL, HTTP: srv.Client(), Cache: c}
+	ctx := context.Background()
+	fetch := func() string {
+		t.Helper()
+		body, err := client.FileContent(ctx, "acme/tools", ".claude/agents/reviewer.md", "main")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return body
+	}
+
+	if got := fetch(); got != "# Reviewer\n" {
+		t.Fatalf("first fetch = %q", got)
+	}
+
+	// Fresh: served without a request
+	if got := fetch(); got != "# Reviewer\n" || requests.Load() != 1 {
+		t.Errorf("fresh fetch = %q after %d requests, want 1 request", got, requests.Load())
+	}
+
+	// Stale: revalidated with the ETag, and the 304 reuses the stored body
+	key := "api application/vnd.github.raw " + srv.URL + "/repos/acme/tools/contents/.claude/agents/reviewer.md?ref=main"
+	age(t, c, key, contentCacheTTL+time.Minute)
+	if got := fetch(); got != "# Reviewer\n" || notModified.Load() != 1 {
+		t.Errorf("stale fetch = %q with %d 304s, want the cached body from one 304", got, notModified.Load())
+	}
+
+	// The 304 refreshed the entry, so the next fetch is served locally again
+	if got := fetch(); got != "# Reviewer\n" || requests.Load() != 2 {
+		t.Errorf("after revalidation: %q after %d requests, want 2 requests", got, requests.Load())
+	}
+}
+
+func TestSearchCacheKey(t *testing.T) {
+	opts := SearchOptions{SearchMode: ModeAgents, MatchMode: "all", Limit: 100}
+	t.Setenv("GH_HOST", "")
+
+	keys := map[string]string{
+		"gh":         searchCacheKey(GHSearcher{}, "reviewer", opts),
+		"http":       searchCacheKey(&HTTPSearcher{Client: &Client{BaseURL: defaultBaseURL}}, "reviewer", opts),
+		"enterprise": searchCacheKey(&HTTPSearcher{Client: &Client{BaseURL: "https://ghe.example.com/api/v3"}}, "reviewer", opts),
+	}
+	seen := make(map[string]string)
+	for name, key := range keys {
+		if other, ok := seen[key]; ok {
+			t.Errorf("%s and %s share the cache key %q", name, other, key)
+		}
+		seen[key] = name
+	}
+
+	t.Setenv("GH_HOST", "ghe.example.com")
+	if key := searchCacheKey(GHSearcher{}, "reviewer", opts); key == keys["gh"] {
+		t.Errorf("gh against another host reuses %q", key)
+	}
+}
@@ -0,0 +1,384 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Client is a minimal GitHub REST API client built on net/http
+type Client struct {
+	BaseURL string       // API root, e.g. https://api.github.com or https://ghe.example.com/api/v3
+	Token   string       // Bearer token, empty for anonymous access
+	HTTP    *http.Client // Underlying HTTP client
+	Cache   *Cache       // Stores contents responses for ETag revalidation; nil disables caching
+}
+
+// ContentItem is an entry returned by the repository contents API
+type ContentItem struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+	Type string `json:"type"` // "file" or "dir"
+
+	DownloadURL string `json:"download_url,omitempty"` // Raw file URL, empty for directories
+}
+
+// defaultBaseURL is the public GitHub API root
+const defaultBaseURL = "https://api.github.com"
+
+// DefaultClient is shared by the HTTP search backend and the repository browser
+var DefaultClient = NewClient()
+
+// NewClient creates a client configured from the environment.
+// The token comes from GITHUB_TOKEN or GH_TOKEN and the base URL from GITHUB_API_URL.
+func NewClient() *Client {
+	token := os.Getenv("GITHUB_TOKEN")
+	if token == "" {
+		token = os.Getenv("GH_TOKEN")
+	}
+	baseURL := os.Getenv("GITHUB_API_URL")
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+	return &Client{
+		BaseURL: strings.TrimSuffix(baseURL, "/"),
+		Token:   token,
+		HTTP:    http.DefaultClient,
+		Cache:   DefaultCache,
+	}
+}
+
+// SearchCodePages runs a code search and hands each page to fn as it arrives.
+// Pagination stops when fn returns false or there are no more pages.
+func (c *Client) SearchCodePages(ctx context.Context, query string, fn func(page []Result) bool) error {
+	params := url.Values{}
+	params.Set("q", query)
+	params.Set("per_page", "100")
+
+	next := "/search/code?" + params.Encode()
+	retried := false
+	for next != "" {
+		// Hold off until the search budget allows another request
+		if err := rates.wait(ctx, codeSearchResource); err != nil {
+			return err
+		}
+
+		var body struct {
+			Items []struct {
+				Path       string `json:"path"`
+				HTMLURL    string `json:"html_url"`
+				Repository struct {
+					FullName string `json:"full_name"`
+				} `json:"repository"`
+			} `json:"items"`
+		}
+
+		resp, err := c.get(ctx, next, "application/vnd.github+json")
+		if errors.Is(err, ErrRateLimited) && !retried {
+			// The budget ran out under us, or a secondary limit asked us to back off;
+			// wait until the response said to and retry once
+			retried = true
+			if err := waitRetry(ctx, err, 0); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		retried = false
+		err = decodeJSON(resp, &body)
+		next = nextPageURL(resp.Header.Get("Link"))
+		if err != nil {
+			return err
+		}
+
+		page := make([]Result, 0, len(body.Items))
+		for _, item := range body.Items {
+			page = append(page, Result{
+				Repo:    item.Repository.FullName,
+				Path:    item.Path,
+				URL:     item.HTMLURL,
+				RelPath: relPathFor(item.Repository.FullName, item.Path),
+			})
+		}
+		if !fn(page) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// Repository returns metadata for owner/repo from the REST API
+func (c *Client) Repository(ctx context.Context, repo string) (RepoInfo, error) {
+	var body struct {
+		Stars    int       `json:"stargazers_count"`
+		Fork     bool      `json:"fork"`
+		Archived bool      `json:"archived"`
+		PushedAt time.Time `json:"pushed_at"`
+		License  *struct {
+			SpdxID string `json:"spdx_id"`
+		} `json:"license"`
+	}
+	if err := c.getJSON(ctx, "/repos/"+repo, &body); err != nil {
+		return RepoInfo{}, err
+	}
+
+	info := RepoInfo{
+		Stars:    body.Stars,
+		Fork:     body.Fork,
+		Archived: body.Archived,
+		PushedAt: body.PushedAt,
+	}
+	if body.License != nil {
+		info.License = body.License.SpdxID
+	}
+	return info, nil
+}
+
+// fetchRepoInfoREST looks up repositories one request at a time, five in parallel.
+// It is the fallback for anonymous clients, since GraphQL requires a token.
+func (c *Client) fetchRepoInfoREST(ctx context.Context, repos []string) map[string]RepoInfo {
+	info := make(map[string]RepoInfo)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	// Limit concurrent requests
+	sem := make(chan struct{}, 5)
+
+	for _, repo := range repos {
+		wg.Add(1)
+		go func(r string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			if ri, err := c.Repository(ctx, r); err == nil {
+				mu.Lock()
+				info[r] = ri
+				mu.Unlock()
+			}
+		}(repo)
+	}
+
+	wg.Wait()
+	return info
+}
+
+// Contents lists a directory in a repository at ref, following pagination.
+// An empty ref means the default branch.
+func (c *Client) Contents(ctx context.Context, repo, path, ref string) ([]ContentItem, error) {
+	next := fmt.Sprintf("/repos/%s/contents", repo)
+	if path != "" {
+		next += "/" + path
+	}
+	if ref != "" {
+		next += "?ref=" + url.QueryEscape(ref)
+	}
+
+	var items []ContentItem
+	for next != "" {
+		body, link, err := c.getCached(ctx, next, "application/vnd.github+json")
+		if err != nil {
+			return nil, err
+		}
+		var page []ContentItem
+		if err := json.Unmarshal(body, &page); err != nil {
+			return nil, fmt.Errorf("failed to decode response: %w", err)
+		}
+		items = append(items, page...)
+		next = nextPageURL(link)
+	}
+	return items, nil
+}
+
+// FileContent fetches the raw contents of a file in a repository at ref. An
+// empty ref means the default branch.
+func (c *Client) FileContent(ctx context.Context, repo, path, ref string) (string, error) {
+	contents := fmt.Sprintf("/repos/%s/contents/%s", repo, path)
+	if ref != "" {
+		contents += "?ref=" + url.QueryEscape(ref)
+	}
+	body, _, err := c.getCached(ctx, contents, "application/vnd.github.raw")
+	if err != nil {
+		return "", err
+	}
+	return string(body), nil
+}
+
+// Download fetches a file by its blob URL. An anonymous client of the public API
+// reads raw.githubusercontent.com, which costs no API quota; with a token or
+// another API root the file comes through the authenticated contents API, so
+// private repositories, GitHub Enterprise and stand-in servers work.
+func (c *Client) Download(ctx context.Context, blobURL string) (string, error) {
+	repo, ref, path, ok := ParseBlobURL(blobURL)
+	if !ok {
+		return "", fmt.Errorf("not a file URL: %s", blobURL)
+	}
+	if c.Token == "" && c.BaseURL == defaultBaseURL {
+		return c.RawFile(ctx, fmt.Sprintf("https://raw.githubusercontent.com/%s/%s/%s", repo, ref, path))
+	}
+	if ref == "HEAD" {
+		ref = ""
+	}
+	return c.FileContent(ctx, repo, path, ref)
+}
+
+// RawFile downloads a raw.githubusercontent.com (or other unauthenticated) URL,
+// serving repeat requests from the cache and revalidating them by ETag
+func (c *Client) RawFile(ctx context.Context, rawURL string) (string, error) {
+	key := "raw " + rawURL
+	entry, cached := c.Cache.get(key)
+	if cached && entry.fresh(contentCacheTTL) {
+		return string(entry.Body), nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
+	if err != nil {
+		return "", err
+	}
+	if cached && entry.ETag != "" {
+		req.Header.Set("If-None-Match", entry.ETag)
+	}
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to download: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && cached {
+		c.Cache.touch(entry)
+		return string(entry.Body), nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+	c.Cache.put(key, resp.Header.Get("ETag"), "", body)
+	return string(body), nil
+}
+
+// getCached performs a GET through the cache. Fresh entries are served without a
+// request; stale ones are revalidated with If-None-Match, and a 304 reply (which
+// does not count against the rate limit) reuses the stored body.
+func (c *Client) getCached(ctx context.Context, path, accept string) ([]byte, string, error) {
+	key := "api " + accept + " " + c.resolve(path)
+	entry, cached := c.Cache.get(key)
+	if cached && entry.fresh(contentCacheTTL) {
+		return entry.Body, entry.Link, nil
+	}
+
+	etag := ""
+	if cached {
+		etag = entry.ETag
+	}
+	resp, err := c.request(ctx, path, accept, etag)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		c.Cache.touch(entry)
+		return entry.Body, entry.Link, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read response: %w", err)
+	}
+	link := resp.Header.Get("Link")
+	c.Cache.put(key, resp.Header.Get("ETag"), link, body)
+	return body, link, nil
+}
+
+// getJSON performs a GET and decodes the JSON response into v
+func (c *Client) getJSON(ctx context.Context, path string, v interface{}) error {
+	resp, err := c.get(ctx, path, "application/vnd.github+json")
+	if err != nil {
+		return err
+	}
+	return decodeJSON(resp, v)
+}
+
+// get performs an authenticated GET against a path or an absolute URL from a Link header
+func (c *Client) get(ctx context.Context, path, accept string) (*http.Response, error) {
+	return c.request(ctx, path, accept, "")
+}
+
+// request performs an authenticated GET, sending If-None-Match when etag is set.
+// A 304 Not Modified response is only returned to callers that sent an etag.
+func (c *Client) request(ctx context.Context, path, accept, etag string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.resolve(path), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", accept)
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("GET %s: %w", path, err)
+	}
+	rates.observe(resp.Header)
+	if resp.StatusCode == http.StatusNotModified && etag != "" {
+		return resp, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, httpError(path, resp)
+	}
+	return resp, nil
+}
+
+// resolve turns an API path into an absolute URL; absolute URLs pass through
+func (c *Client) resolve(path string) string {
+	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		return path
+	}
+	return c.BaseURL + path
+}
+
+// decodeJSON decodes and closes a response body
+func decodeJSON(resp *http.Response, v interface{}) error {
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// nextPageURL extracts the rel="next" target from a Link header
+func nextPageURL(link string) string {
+	for _, part := range strings.Split(link, ",") {
+		sections := strings.Split(part, ";")
+		if len(sections) < 2 {
+			continue
+		}
+		for _, param := range sections[1:] {
+			if strings.TrimSpace(param) == `rel="next"` {
+				return strings.Trim(strings.TrimSpace(sections[0]), "<>")
+			}
+		}
+	}
+	return ""
+}
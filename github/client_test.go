@@ -0,0 +1,181 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// testServer serves handler and points a client built by NewClient at it through
+// GITHUB_API_URL, the way a GitHub Enterprise install would be configured
+func testServer(t *testing.T, token string, handler http.HandlerFunc) (*Client, *httptest.Server) {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	t.Setenv("GITHUB_API_URL", srv.URL+"/")
+	t.Setenv("GITHUB_TOKEN", token)
+	t.Setenv("GH_TOKEN", "")
+	c := NewClient()
+	c.HTTP = srv.Client()
+	c.Cache = testCache(t)
+	return c, srv
+}
+
+func TestNewClient(t *testing.T) {
+	t.Setenv("GITHUB_API_URL", "")
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("GH_TOKEN", "gh-token")
+	c := NewClient()
+	if c.BaseURL != defaultBaseURL || c.Token != "gh-token" {
+		t.Errorf("client = %q with token %q, want %q with GH_TOKEN", c.BaseURL, c.Token, defaultBaseURL)
+	}
+
+	t.Setenv("GITHUB_TOKEN", "github-token")
+	t.Setenv("GITHUB_API_URL", "https://ghe.example.com/api/v3/")
+	c = NewClient()
+	if c.BaseURL != "https://ghe.example.com/api/v3" || c.Token != "github-token" {
+		t.Errorf("client = %q with token %q, want the GHE root and GITHUB_TOKEN", c.BaseURL, c.Token)
+	}
+}
+
+func TestClientSendsToken(t *testing.T) {
+	tests := []struct {
+		token string
+		want  string
+	}{
+		{token: "secret", want: "Bearer secret"},
+		{token: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		var got string
+		c, _ := testServer(t, tt.token, func(w http.ResponseWriter, r *http.Request) {
+			got = r.Header.Get("Authorization")
+			w.Write([]byte(`{"stargazers_count": 3}`))
+		})
+		if _, err := c.Repository(context.Background(), "acme/tools"); err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("token %q sent Authorization %q, want %q", tt.token, got, tt.want)
+		}
+	}
+}
+
+func TestSearchCodePages(t *testing.T) {
+	var mu sync.Mutex
+	var pages []string
+	var srv *httptest.Server
+	c, srv := testServer(t, "secret", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		pages = append(pages, r.URL.Query().Get("page"))
+		mu.Unlock()
+
+		switch r.URL.Query().Get("page") {
+		case "":
+			w.Header().Set("Link", `<`+srv.URL+`/search/code?q=x&page=2>; rel="next", <`+srv.URL+`/search/code?q=x&page=3>; rel="last"`)
+			w.Write([]byte(`{"items": [{"path": ".claude/agents/a.md", "html_url": "https://github.com/acme/tools/blob/abc/.claude/agents/a.md", "repository": {"full_name": "acme/tools"}}]}`))
+		case "2":
+			w.Header().Set("Link", `<`+srv.URL+`/search/code?q=x&page=3>; rel="next", <`+srv.URL+`/search/code?q=x&page=1>; rel="first"`)
+			w.Write([]byte(`{"items": [{"path": ".claude/agents/b.md", "html_url": "https://github.com/other/x/blob/def/.claude/agents/b.md", "repository": {"full_name": "other/x"}}]}`))
+		case "3":
+			// The last page has no rel="next"
+			w.Header().Set("Link", `<`+srv.URL+`/search/code?q=x&page=1>; rel="first"`)
+			w.Write([]byte(`{"items": []}`))
+		default:
+			t.Errorf("requested page %s past the last", r.URL.Query().Get("page"))
+		}
+	})
+
+	var repos []string
+	err := c.SearchCodePages(context.Background(), "path:.claude/agents", func(page []Result) bool {
+		for _, r := range page {
+			repos = append(repos, r.Repo)
+		}
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"acme/tools", "other/x"}; !reflect.DeepEqual(repos, want) {
+		t.Errorf("repos = %q, want %q", repos, want)
+	}
+	if want := []string{"", "2", "3"}; !reflect.DeepEqual(pages, want) {
+		t.Errorf("pages = %q, want %q", pages, want)
+	}
+
+	// Returning false stops before the next page
+	pages = nil
+	err = c.SearchCodePages(context.Background(), "path:.claude/agents", func([]Result) bool { return false })
+	if err != nil || len(pages) != 1 {
+		t.Errorf("stopped early after %d pages (err %v), want 1", len(pages), err)
+	}
+}
+
+func TestContentsFollowsPagination(t *testing.T) {
+	var srv *httptest.Server
+	c, srv := testServer(t, "", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "2" {
+			w.Write([]byte(`[{"name": "b.md", "path": ".claude/agents/b.md", "type": "file"}]`))
+			return
+		}
+		if got := r.URL.Query().Get("ref"); got != "feature/x" {
+			t.Errorf("ref = %q, want feature/x", got)
+		}
+		w.Header().Set("Link", `<`+srv.URL+`/repos/acme/tools/contents/.claude/agents?ref=feature%2Fx&page=2>; rel="next"`)
+		w.Write([]byte(`[{"name": "a.md", "path": ".claude/agents/a.md", "type": "file"}]`))
+	})
+
+	items, err := c.Contents(context.Background(), "acme/tools", ".claude/agents", "feature/x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 || items[0].Name != "a.md" || items[1].Name != "b.md" {
+		t.Errorf("items = %+v, want both pages", items)
+	}
+}
+
+func TestClientUnauthenticated(t *testing.T) {
+	c, _ := testServer(t, "expired", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"message": "Bad credentials"}`))
+	})
+
+	ctx := context.Background()
+	if _, err := c.Repository(ctx, "acme/tools"); !errors.Is(err, ErrUnauthenticated) {
+		t.Errorf("Repository = %v, want ErrUnauthenticated", err)
+	}
+	err := c.SearchCodePages(ctx, "path:.claude/agents", func([]Result) bool { return true })
+	if !errors.Is(err, ErrUnauthenticated) {
+		t.Errorf("SearchCodePages = %v, want ErrUnauthenticated", err)
+	}
+	if _, err := c.FileContent(ctx, "acme/tools", "CLAUDE.md", ""); !errors.Is(err, ErrUnauthenticated) {
+		t.Errorf("FileContent = %v, want ErrUnauthenticated", err)
+	}
+}
+
+func TestClientNotModified(t *testing.T) {
+	c, _ := testServer(t, "secret", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotModified)
+	})
+
+	ctx := context.Background()
+	resp, err := c.request(ctx, "/repos/acme/tools/contents/CLAUDE.md", "application/vnd.github.raw", `"v1"`)
+	if err != nil {
+		t.Fatalf("conditional request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotModified {
+		t.Errorf("status = %d, want 304", resp.StatusCode)
+	}
+
+	// A 304 nobody asked for is an error, not an empty success
+	if _, err := c.get(ctx, "/repos/acme/tools/contents/CLAUDE.md", "application/vnd.github.raw"); err == nil {
+		t.Error("unconditional request accepted a 304")
+	}
+}
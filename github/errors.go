@@ -0,0 +1,103 @@
+package github
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrRateLimited is matched by errors.Is for any RateLimitError
+	ErrRateLimited = errors.New("GitHub rate limit exceeded")
+
+	// ErrUnauthenticated means the request needs a token, or the token was rejected
+	ErrUnauthenticated = errors.New("GitHub authentication required")
+
+	// ErrBackendMissing means the selected backend's tooling (the gh CLI) is not installed
+	ErrBackendMissing = errors.New("gh CLI not found")
+)
+
+// RateLimitError reports an exhausted rate limit and when it resets
+type RateLimitError struct {
+	Reset time.Time // Zero when GitHub did not say
+}
+
+func (e *RateLimitError) Error() string {
+	if e.Reset.IsZero() {
+		return ErrRateLimited.Error()
+	}
+	return fmt.Sprintf("%s, resets at %s", ErrRateLimited, e.Reset.Local().Format("15:04:05"))
+}
+
+// Is lets errors.Is(err, ErrRateLimited) match
+func (e *RateLimitError) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+// RetryAfter returns how long to wait before the limit resets
+func (e *RateLimitError) RetryAfter() time.Duration {
+	if e.Reset.IsZero() {
+		return 0
+	}
+	return time.Until(e.Reset)
+}
+
+// httpError classifies a non-200 API response
+func httpError(path string, resp *http.Response) error {
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		return fmt.Errorf("GET %s: %w", path, ErrUnauthenticated)
+	case http.StatusForbidden, http.StatusTooManyRequests:
+		if resp.Header.Get("X-RateLimit-Remaining") == "0" || resp.Header.Get("Retry-After") != "" {
+			return &RateLimitError{Reset: retryTime(resp.Header)}
+		}
+	}
+	return fmt.Errorf("GET %s: %s", path, resp.Status)
+}
+
+// retryTime is when a rate-limited request may be tried again. A secondary limit
+// says so in Retry-After, which is usually much sooner than the primary reset.
+func retryTime(h http.Header) time.Time {
+	if secs, err := strconv.Atoi(h.Get("Retry-After")); err == nil {
+		return time.Now().Add(time.Duration(secs) * time.Second)
+	}
+	return resetTime(h)
+}
+
+// resetTime reads X-RateLimit-Reset
+func resetTime(h http.Header) time.Time {
+	if epoch, err := strconv.ParseInt(h.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		return time.Unix(epoch, 0)
+	}
+	return time.Time{}
+}
+
+// ghError classifies a failed gh invocation using its exit error and stderr.
+// Rate limits are not guessed from the message; calls that can hit one go
+// through ghAPI, which classifies them by their response headers.
+func ghError(err error) error {
+	if errors.Is(err, exec.ErrNotFound) {
+		return ErrBackendMissing
+	}
+
+	stderr := ""
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		stderr = strings.ToLower(string(exitErr.Stderr))
+	}
+
+	switch {
+	case strings.Contains(stderr, "gh auth login"), strings.Contains(stderr, "authentication"),
+		strings.Contains(stderr, "http 401"):
+		return ErrUnauthenticated
+	}
+
+	if stderr != "" {
+		return fmt.Errorf("gh: %s", strings.TrimSpace(stderr))
+	}
+	return fmt.Errorf("gh: %w", err)
+}
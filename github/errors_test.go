@@ -0,0 +1,161 @@
+package github
+
+import (
+	"errors"
+	"net/http"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func TestHTTPError(t *testing.T) {
+	reset := time.Unix(1767225600, 0)
+
+	tests := []struct {
+		name      string
+		status    int
+		header    http.Header
+		want      error
+		wantReset time.Time
+	}{
+		{name: "unauthorized", status: http.StatusUnauthorized, want: ErrUnauthenticated},
+		{
+			name:      "primary limit spent",
+			status:    http.StatusForbidden,
+			header:    http.Header{"X-Ratelimit-Remaining": {"0"}, "X-Ratelimit-Reset": {"1767225600"}},
+			want:      ErrRateLimited,
+			wantReset: reset,
+		},
+		{
+			name:   "secondary limit",
+			status: http.StatusForbidden,
+			header: http.Header{"X-Ratelimit-Remaining": {"12"}, "X-Ratelimit-Reset": {"1767225600"}, "Retry-After": {"60"}},
+			want:   ErrRateLimited,
+		},
+		{name: "too many requests", status: http.StatusTooManyRequests, header: http.Header{"Retry-After": {"5"}}, want: ErrRateLimited},
+		{name: "forbidden for another reason", status: http.StatusForbidden, header: http.Header{"X-Ratelimit-Remaining": {"12"}}},
+		{name: "not found", status: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{StatusCode: tt.status, Status: http.StatusText(tt.status), Header: tt.header}
+			err := httpError("/search/code", resp)
+			if err == nil {
+				t.Fatal("no error")
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("error = %v, want %v", err, tt.want)
+			}
+			if tt.want == nil && (errors.Is(err, ErrRateLimited) || errors.Is(err, ErrUnauthenticated)) {
+				t.Errorf("error = %v, want a plain HTTP error", err)
+			}
+
+			var rle *RateLimitError
+			if !errors.As(err, &rle) {
+				return
+			}
+			if !tt.wantReset.IsZero() && !rle.Reset.Equal(tt.wantReset) {
+				t.Errorf("reset = %v, want %v", rle.Reset, tt.wantReset)
+			}
+			// Retry-After wins over the primary reset, which may be an hour away
+			if tt.header.Get("Retry-After") != "" && rle.RetryAfter() > time.Minute+time.Second {
+				t.Errorf("retry after %v, want Retry-After to be used", rle.RetryAfter())
+			}
+		})
+	}
+}
+
+func TestGHError(t *testing.T) {
+	exitErr := func(stderr string) error {
+		return &exec.ExitError{Stderr: []byte(stderr)}
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{name: "gh not installed", err: &exec.Error{Name: "gh", Err: exec.ErrNotFound}, want: ErrBackendMissing},
+		{name: "not logged in", err: exitErr("To get started with GitHub CLI, please run:  gh auth login\n"), want: ErrUnauthenticated},
+		{name: "bad credentials", err: exitErr("gh: Bad credentials (HTTP 401)\n"), want: ErrUnauthenticated},
+		// Rate limits are read from headers, not guessed from the message
+		{name: "rate limit message", err: exitErr("gh: API rate limit exceeded for user ID 1. (HTTP 403)\n")},
+		{name: "other failure", err: exitErr("gh: Not Found (HTTP 404)\n")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ghError(tt.err)
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("ghError = %v, want %v", err, tt.want)
+			}
+			if tt.want == nil && (errors.Is(err, ErrRateLimited) || errors.Is(err, ErrUnauthenticated) || errors.Is(err, ErrBackendMissing)) {
+				t.Errorf("ghError = %v, want a plain gh error", err)
+			}
+		})
+	}
+}
+
+func TestParseGHResponse(t *testing.T) {
+	output := "HTTP/2.0 403 Forbidden\r\n" +
+		"Content-Type: application/json; charset=utf-8\r\n" +
+		"X-Ratelimit-Limit: 10\r\n" +
+		"X-Ratelimit-Remaining: 0\r\n" +
+		"X-Ratelimit-Reset: 1767225600\r\n" +
+		"X-Ratelimit-Resource: code_search\r\n" +
+		"\r\n" +
+		`{"message": "API rate limit exceeded"}`
+
+	resp, body, ok := parseGHResponse([]byte(output))
+	if !ok {
+		t.Fatal("could not parse gh api -i output")
+	}
+	if resp.StatusCode != http.StatusForbidden || resp.Status != "403 Forbidden" {
+		t.Errorf("status = %d %q, want 403 Forbidden", resp.StatusCode, resp.Status)
+	}
+	if got := string(body); got != `{"message": "API rate limit exceeded"}` {
+		t.Errorf("body = %q", got)
+	}
+
+	var rle *RateLimitError
+	if err := httpError("search/code", resp); !errors.As(err, &rle) || !rle.Reset.Equal(time.Unix(1767225600, 0)) {
+		t.Errorf("httpError = %v, want a rate limit resetting at the header's time", err)
+	}
+
+	tracker := &rateTracker{limits: make(map[string]RateLimit)}
+	tracker.observe(resp.Header)
+	if got := tracker.get(codeSearchResource); got.Limit != 10 || got.Remaining != 0 {
+		t.Errorf("code_search = %+v, want 0 of 10 left", got)
+	}
+
+	// A success with LF line endings and a Link header for the next page
+	resp, body, ok = parseGHResponse([]byte("HTTP/1.1 200 OK\nLink: <https://api.github.com/search/code?page=2>; rel=\"next\"\n\n{\"items\": []}"))
+	if !ok || resp.StatusCode != http.StatusOK || string(body) != `{"items": []}` {
+		t.Errorf("parsed %+v, %q, %v", resp, body, ok)
+	}
+	if nextPageURL(resp.Header.Get("Link")) == "" {
+		t.Error("lost the Link header")
+	}
+
+	for _, bad := range []string{"", "gh: not found\n", "HTTP/2.0 abc\r\n\r\n"} {
+		if _, _, ok := parseGHResponse([]byte(bad)); ok {
+			t.Errorf("parsed %q, want it rejected", bad)
+		}
+	}
+}
+
+func TestParseSearchResults(t *testing.T) {
+	data := `{"total_count": 2, "items": [
+		{"path": ".claude/agents/reviewer.md", "html_url": "https://github.com/acme/tools/blob/abc/.claude/agents/reviewer.md", "repository": {"full_name": "acme/tools"}},
+		{"path": "docs/reviewer.md", "html_url": "https://github.com/acme/tools/blob/abc/docs/reviewer.md", "repository": {"full_name": "acme/tools"}}
+	]}`
+	results, err := parseSearchResults([]byte(data), SearchOptions{SearchMode: ModeAgents})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Repo != "acme/tools" || results[0].RelPath != "acme/tools/reviewer.md" ||
+		results[0].URL != "https://github.com/acme/tools/blob/abc/.claude/agents/reviewer.md" {
+		t.Errorf("results = %+v, want just the agent", results)
+	}
+}
@@ -0,0 +1,247 @@
+package github
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Metadata is what an artifact's frontmatter says about it
+type Metadata struct {
+	Name        string   `json:"name,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Tools       []string `json:"tools,omitempty"`
+	Model       string   `json:"model,omitempty"`
+}
+
+// ParseMetadata reads the frontmatter at the top of a markdown file. ok is false
+// when the file has none.
+func ParseMetadata(content string) (Metadata, bool) {
+	fields, _, ok := SplitFrontmatter(content)
+	if !ok {
+		return Metadata{}, false
+	}
+
+	meta := Metadata{
+		Name:        fields["name"],
+		Description: fields["description"],
+		Model:       fields["model"],
+	}
+	// tools is either a YAML list or a comma separated string
+	for _, tool := range strings.Split(fields["tools"], ",") {
+		if tool = strings.TrimSpace(tool); tool != "" {
+			meta.Tools = append(meta.Tools, tool)
+		}
+	}
+	return meta, true
+}
+
+// SplitFrontmatter separates a markdown file's YAML frontmatter from its body and
+// parses the top-level keys. Only the subset of YAML frontmatter uses is handled:
+// plain, quoted and block scalars, and flow or block lists, which are joined with
+// ", ". Nested mappings are skipped.
+func SplitFrontmatter(content string) (fields map[string]string, body string, ok bool) {
+	content = strings.TrimPrefix(content, "\ufeff")
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	if len(lines) == 0 || strings.TrimSpace(lines[0]) != "---" {
+		return nil, content, false
+	}
+
+	end := -1
+	for i := 1; i < len(lines); i++ {
+		if t := strings.TrimRight(lines[i], " \t"); t == "---" || t == "..." {
+			end = i
+			break
+		}
+	}
+	if end < 0 {
+		return nil, content, false
+	}
+
+	return parseYAMLMapping(lines[1:end]), strings.Join(lines[end+1:], "\n"), true
+}
+
+// parseYAMLMapping parses the top-level keys of a flat YAML mapping
+func parseYAMLMapping(lines []string) map[string]string {
+	fields := make(map[string]string)
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if isYAMLIndented(line) || strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+
+		// Gather the indented lines that belong to this key
+		var nested []string
+		for i+1 < len(lines) && (isYAMLIndented(lines[i+1]) || strings.TrimSpace(lines[i+1]) == "") {
+			i++
+			nested = append(nested, lines[i])
+		}
+
+		switch {
+		case strings.HasPrefix(value, "|") || strings.HasPrefix(value, ">"):
+			fields[key] = yamlBlockScalar(nested, value[0] == '>')
+		case value == "":
+			fields[key] = yamlBlockList(nested)
+		case strings.HasPrefix(value, "["):
+			fields[key] = yamlFlowList(value)
+		default:
+			// Plain scalars may continue on indented lines
+			parts := []string{yamlScalar(value)}
+			for _, l := range nested {
+				if t := strings.TrimSpace(l); t != "" {
+					parts = append(parts, t)
+				}
+			}
+			fields[key] = strings.Join(parts, " ")
+		}
+	}
+
+	return fields
+}
+
+func isYAMLIndented(line string) bool {
+	return strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")
+}
+
+// yamlScalar unquotes a scalar and strips a trailing comment
+func yamlScalar(value string) string {
+	switch {
+	case strings.HasPrefix(value, `"`):
+		if end := strings.LastIndex(value, `"`); end > 0 {
+			if s, err := strconv.Unquote(value[:end+1]); err == nil {
+				return s
+			}
+			return value[1:end]
+		}
+	case strings.HasPrefix(value, "'"):
+		if end := strings.LastIndex(value, "'"); end > 0 {
+			return strings.ReplaceAll(value[1:end], "''", "'")
+		}
+	}
+	if idx := strings.Index(value, " #"); idx >= 0 {
+		value = value[:idx]
+	}
+	return strings.TrimSpace(value)
+}
+
+// yamlBlockScalar joins a | (literal) or > (folded) block
+func yamlBlockScalar(lines []string, folded bool) string {
+	indent := -1
+	for _, l := range lines {
+		if strings.TrimSpace(l) == "" {
+			continue
+		}
+		n := len(l) - len(strings.TrimLeft(l, " \t"))
+		if indent < 0 || n < indent {
+			indent = n
+		}
+	}
+
+	var out []string
+	for _, l := range lines {
+		if len(l) >= indent && indent >= 0 {
+			l = l[indent:]
+		}
+		out = append(out, strings.TrimRight(l, " \t"))
+	}
+	text := strings.TrimRight(strings.Join(out, "\n"), "\n")
+	if folded {
+		// Single newlines fold into spaces; blank lines stay paragraph breaks
+		paragraphs := strings.Split(text, "\n\n")
+		for i, p := range paragraphs {
+			paragraphs[i] = strings.Join(strings.Fields(p), " ")
+		}
+		text = strings.Join(paragraphs, "\n")
+	}
+	return text
+}
+
+// yamlBlockList joins "- item" lines; anything else (a nested mapping) yields ""
+func yamlBlockList(lines []string) string {
+	var items []string
+	for _, l := range lines {
+		t := strings.TrimSpace(l)
+		if t == "" {
+			continue
+		}
+		if !strings.HasPrefix(t, "-") {
+			return ""
+		}
+		items = append(items, yamlScalar(strings.TrimSpace(strings.TrimPrefix(t, "-"))))
+	}
+	return strings.Join(items, ", ")
+}
+
+// yamlFlowList joins a [a, b] list
+func yamlFlowList(value string) string {
+	value = strings.TrimPrefix(value, "[")
+	if end := strings.LastIndex(value, "]"); end >= 0 {
+		value = value[:end]
+	}
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = yamlScalar(strings.TrimSpace(item)); item != "" {
+			items = append(items, item)
+		}
+	}
+	return strings.Join(items, ", ")
+}
+
+// fetchMetadata downloads each result's file and parses its frontmatter, five in
+// parallel through Download. Results without frontmatter, or that
+// could not be fetched, are left out of the returned map.
+func fetchMetadata(ctx context.Context, results []Result) map[string]Metadata {
+	meta := make(map[string]Metadata)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, 5)
+
+	for _, r := range results {
+		wg.Add(1)
+		go func(r Result) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			content, err := DefaultClient.Download(ctx, r.URL)
+			if err != nil {
+				return
+			}
+			if m, ok := ParseMetadata(content); ok {
+				mu.Lock()
+				meta[ResultKey(r)] = m
+				mu.Unlock()
+			}
+		}(r)
+	}
+	wg.Wait()
+
+	return meta
+}
+
+// ApplyMetadata attaches fetched frontmatter to the results it belongs to
+func ApplyMetadata(results []Result, meta map[string]Metadata) {
+	for i := range results {
+		if m, ok := meta[ResultKey(results[i])]; ok {
+			results[i].Meta = &m
+		}
+	}
+}
+
+// ResultKey identifies a result: its repository and path, and for MCP results
+// the server, since one config yields several results
+func ResultKey(r Result) string {
+	key := r.Repo + ":" + r.Path
+	if r.MCPServer != "" {
+		key += "#" + r.MCPServer
+	}
+	return key
+}
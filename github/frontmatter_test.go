@@ -0,0 +1,115 @@
+package github
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitFrontmatter(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		fields  map[string]string
+		body    string
+		ok      bool
+	}{
+		{
+			name:    "no frontmatter",
+			content: "# Reviewer\n",
+			body:    "# Reviewer\n",
+		},
+		{
+			name:    "unterminated",
+			content: "---\nname: reviewer\n# Reviewer\n",
+			body:    "---\nname: reviewer\n# Reviewer\n",
+		},
+		{
+			name:    "plain and quoted scalars",
+			content: "---\nname: reviewer\ndescription: \"Reviews \\\"diffs\\\"\"\nmodel: 'o''neil' # comment\n---\nBody\n",
+			fields:  map[string]string{"name": "reviewer", "description": `Reviews "diffs"`, "model": "o'neil"},
+			body:    "Body\n",
+			ok:      true,
+		},
+		{
+			name:    "trailing comments and continued plain scalars",
+			content: "---\nmodel: sonnet # fast\ndescription: Reviews\n  pull requests\n---\n",
+			fields:  map[string]string{"model": "sonnet", "description": "Reviews pull requests"},
+			ok:      true,
+		},
+		{
+			name:    "literal and folded blocks",
+			content: "---\nliteral: |\n  one\n  two\nfolded: >\n  one\n  two\n\n  three\n---\n",
+			fields:  map[string]string{"literal": "one\ntwo", "folded": "one two\nthree"},
+			ok:      true,
+		},
+		{
+			name:    "flow and block lists",
+			content: "---\ntools: [Read, \"Grep\", Edit]\nskills:\n  - pdf\n  - 'docs'\n---\n",
+			fields:  map[string]string{"tools": "Read, Grep, Edit", "skills": "pdf, docs"},
+			ok:      true,
+		},
+		{
+			name:    "nested mappings are skipped",
+			content: "---\nname: reviewer\nhooks:\n  Stop: notify\n---\n",
+			fields:  map[string]string{"name": "reviewer", "hooks": ""},
+			ok:      true,
+		},
+		{
+			name:    "byte order mark, CRLF and a ... terminator",
+			content: "\ufeff---\r\nname: reviewer\r\n...\r\nBody",
+			fields:  map[string]string{"name": "reviewer"},
+			body:    "Body",
+			ok:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields, body, ok := SplitFrontmatter(tt.content)
+			if ok != tt.ok {
+				t.Fatalf("ok = %v, want %v", ok, tt.ok)
+			}
+			if ok && !reflect.DeepEqual(fields, tt.fields) {
+				t.Errorf("fields = %q, want %q", fields, tt.fields)
+			}
+			if body != tt.body {
+				t.Errorf("body = %q, want %q", body, tt.body)
+			}
+		})
+	}
+}
+
+func TestParseMetadata(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    Metadata
+		ok      bool
+	}{
+		{
+			name:    "tools as a comma separated string",
+			content: "---\nname: reviewer\ndescription: Reviews diffs\ntools: Read, Grep\nmodel: opus\n---\n",
+			want:    Metadata{Name: "reviewer", Description: "Reviews diffs", Tools: []string{"Read", "Grep"}, Model: "opus"},
+			ok:      true,
+		},
+		{
+			name:    "tools as a list",
+			content: "---\ntools:\n  - Read\n  - Grep\n---\n",
+			want:    Metadata{Tools: []string{"Read", "Grep"}},
+			ok:      true,
+		},
+		{
+			name:    "no frontmatter",
+			content: "# Reviewer\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseMetadata(tt.content)
+			if ok != tt.ok || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseMetadata = %+v, %v; want %+v, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
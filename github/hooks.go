@@ -0,0 +1,211 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// HookHandler is one action run when a hook fires
+type HookHandler struct {
+	Type    string `json:"type"`
+	Command string `json:"command,omitempty"`
+	Prompt  string `json:"prompt,omitempty"`
+	Timeout int    `json:"timeout,omitempty"`
+}
+
+// HookMatcher groups the handlers that run for tools matching Matcher
+type HookMatcher struct {
+	Matcher string        `json:"matcher,omitempty"`
+	Hooks   []HookHandler `json:"hooks"`
+}
+
+// Hooks is the `hooks` block of a settings.json, keyed by event (PreToolUse, Stop, ...)
+type Hooks map[string][]HookMatcher
+
+// IsSettingsFile reports whether p is a .claude/settings.json or settings.local.json
+func IsSettingsFile(p string) bool {
+	base := path.Base(p)
+	return (base == "settings.json" || base == "settings.local.json") &&
+		path.Base(path.Dir(p)) == ".claude"
+}
+
+// HookScriptPath is where a hook script goes beneath hooks/: its path below the
+// hooks directory it came from, or just its name
+func HookScriptPath(p string) string {
+	if rel, ok := KindFor(ModeHooks).relPath(p); ok && !IsSettingsFile(p) {
+		return rel
+	}
+	return path.Base(p)
+}
+
+// IsHookScript reports whether p is a script under .claude/hooks/
+func IsHookScript(p string) bool {
+	return !IsSettingsFile(p) && KindFor(ModeHooks).Matches(p)
+}
+
+// ParseHooks extracts the hooks block from a settings.json
+func ParseHooks(data []byte) (Hooks, error) {
+	var settings struct {
+		Hooks Hooks `json:"hooks"`
+	}
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return nil, fmt.Errorf("failed to parse settings: %w", err)
+	}
+	return settings.Hooks, nil
+}
+
+// Events lists the hook events configured, sorted by name
+func (h Hooks) Events() []string {
+	events := make([]string, 0, len(h))
+	for event := range h {
+		events = append(events, event)
+	}
+	sort.Strings(events)
+	return events
+}
+
+// MergeHooks adds hooks to an existing settings.json, keeping every other setting.
+// Handlers join the group with the same matcher; ones already present are skipped,
+// so installing the same hooks twice is a no-op. Handlers in replaced, from an
+// earlier install of the same file, are removed first so an update does not
+// leave stale ones behind. Empty settings start a new file.
+func MergeHooks(settings []byte, hooks, replaced Hooks) ([]byte, error) {
+	top := make(map[string]json.RawMessage)
+	if len(strings.TrimSpace(string(settings))) > 0 {
+		if err := json.Unmarshal(settings, &top); err != nil {
+			return nil, fmt.Errorf("failed to parse settings: %w", err)
+		}
+	}
+
+	merged := make(Hooks)
+	if raw, ok := top["hooks"]; ok {
+		if err := json.Unmarshal(raw, &merged); err != nil {
+			return nil, fmt.Errorf("failed to parse existing hooks: %w", err)
+		}
+	}
+
+	for _, event := range replaced.Events() {
+		for _, group := range replaced[event] {
+			merged[event] = removeMatcher(merged[event], group)
+		}
+		if len(merged[event]) == 0 {
+			delete(merged, event)
+		}
+	}
+	for _, event := range hooks.Events() {
+		for _, group := range hooks[event] {
+			merged[event] = mergeMatcher(merged[event], group)
+		}
+	}
+
+	raw, err := json.Marshal(merged)
+	if err != nil {
+		return nil, err
+	}
+	top["hooks"] = raw
+
+	out, err := json.MarshalIndent(top, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
+
+// mergeMatcher adds a matcher group's handlers to groups
+func mergeMatcher(groups []HookMatcher, group HookMatcher) []HookMatcher {
+	for i := range groups {
+		if groups[i].Matcher != group.Matcher {
+			continue
+		}
+		for _, h := range group.Hooks {
+			if !containsHandler(groups[i].Hooks, h) {
+				groups[i].Hooks = append(groups[i].Hooks, h)
+			}
+		}
+		return groups
+	}
+	return append(groups, HookMatcher{
+		Matcher: group.Matcher,
+		Hooks:   append([]HookHandler(nil), group.Hooks...),
+	})
+}
+
+// removeMatcher drops a matcher group's handlers from groups, along with any
+// group left empty
+func removeMatcher(groups []HookMatcher, group HookMatcher) []HookMatcher {
+	var kept []HookMatcher
+	for _, g := range groups {
+		if g.Matcher == group.Matcher {
+			var handlers []HookHandler
+			for _, h := range g.Hooks {
+				if !containsHandler(group.Hooks, h) {
+					handlers = append(handlers, h)
+				}
+			}
+			if len(handlers) == 0 {
+				continue
+			}
+			g.Hooks = handlers
+		}
+		kept = append(kept, g)
+	}
+	return kept
+}
+
+func containsHandler(handlers []HookHandler, h HookHandler) bool {
+	for _, existing := range handlers {
+		if existing == h {
+			return true
+		}
+	}
+	return false
+}
+
+// annotateHooks fetches each settings file in results and records its hook events.
+// Settings files without a hooks block are dropped; ones that cannot be fetched are
+// kept unannotated. Fetches run five in parallel through Download,
+// which does not count against the API quota.
+func annotateHooks(ctx context.Context, results []Result) []Result {
+	keep := make([]bool, len(results))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, 5)
+
+	for i := range results {
+		if !IsSettingsFile(results[i].Path) {
+			keep[i] = true
+			continue
+		}
+		wg.Add(1)
+		go func(r *Result, keep *bool) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			content, err := DefaultClient.Download(ctx, r.URL)
+			if err != nil {
+				*keep = true
+				return
+			}
+			hooks, err := ParseHooks([]byte(content))
+			if err != nil || len(hooks) == 0 {
+				return
+			}
+			r.HookEvents = hooks.Events()
+			*keep = true
+		}(&results[i], &keep[i])
+	}
+	wg.Wait()
+
+	var kept []Result
+	for i, r := range results {
+		if keep[i] {
+			kept = append(kept, r)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,147 @@
+package github
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestMergeHooks(t *testing.T) {
+	lint := HookHandler{Type: "command", Command: "lint"}
+	format := HookHandler{Type: "command", Command: "format"}
+	notify := HookHandler{Type: "command", Command: "notify"}
+
+	tests := []struct {
+		name     string
+		settings string
+		hooks    Hooks
+		replaced Hooks
+		want     Hooks
+	}{
+		{
+			name:     "starts a new file",
+			settings: "",
+			hooks:    Hooks{"PostToolUse": {{Matcher: "Edit", Hooks: []HookHandler{lint}}}},
+			want:     Hooks{"PostToolUse": {{Matcher: "Edit", Hooks: []HookHandler{lint}}}},
+		},
+		{
+			name:     "joins the group with the same matcher",
+			settings: `{"hooks": {"PostToolUse": [{"matcher": "Edit", "hooks": [{"type": "command", "command": "lint"}]}]}}`,
+			hooks:    Hooks{"PostToolUse": {{Matcher: "Edit", Hooks: []HookHandler{format}}}},
+			want:     Hooks{"PostToolUse": {{Matcher: "Edit", Hooks: []HookHandler{lint, format}}}},
+		},
+		{
+			name:     "skips handlers already present",
+			settings: `{"hooks": {"PostToolUse": [{"matcher": "Edit", "hooks": [{"type": "command", "command": "lint"}]}]}}`,
+			hooks:    Hooks{"PostToolUse": {{Matcher: "Edit", Hooks: []HookHandler{lint}}}},
+			want:     Hooks{"PostToolUse": {{Matcher: "Edit", Hooks: []HookHandler{lint}}}},
+		},
+		{
+			name:     "adds a group for a new matcher",
+			settings: `{"hooks": {"PostToolUse": [{"matcher": "Edit", "hooks": [{"type": "command", "command": "lint"}]}]}}`,
+			hooks:    Hooks{"PostToolUse": {{Matcher: "Write", Hooks: []HookHandler{lint}}}},
+			want: Hooks{"PostToolUse": {
+				{Matcher: "Edit", Hooks: []HookHandler{lint}},
+				{Matcher: "Write", Hooks: []HookHandler{lint}},
+			}},
+		},
+		{
+			name:     "replaces the handlers of an earlier install",
+			settings: `{"hooks": {"PostToolUse": [{"matcher": "Edit", "hooks": [{"type": "command", "command": "lint"}, {"type": "command", "command": "notify"}]}]}}`,
+			hooks:    Hooks{"PostToolUse": {{Matcher: "Edit", Hooks: []HookHandler{format}}}},
+			replaced: Hooks{"PostToolUse": {{Matcher: "Edit", Hooks: []HookHandler{lint}}}},
+			want:     Hooks{"PostToolUse": {{Matcher: "Edit", Hooks: []HookHandler{notify, format}}}},
+		},
+		{
+			name:     "drops groups and events left empty",
+			settings: `{"hooks": {"Stop": [{"hooks": [{"type": "command", "command": "notify"}]}]}}`,
+			hooks:    Hooks{"PostToolUse": {{Matcher: "Edit", Hooks: []HookHandler{lint}}}},
+			replaced: Hooks{"Stop": {{Hooks: []HookHandler{notify}}}},
+			want:     Hooks{"PostToolUse": {{Matcher: "Edit", Hooks: []HookHandler{lint}}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := MergeHooks([]byte(tt.settings), tt.hooks, tt.replaced)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := ParseHooks(out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("hooks = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeHooksKeepsSettings(t *testing.T) {
+	settings := `{"model": "opus", "permissions": {"allow": ["Bash"]}}`
+	out, err := MergeHooks([]byte(settings), Hooks{"Stop": {{Hooks: []HookHandler{{Type: "command", Command: "notify"}}}}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var top map[string]json.RawMessage
+	if err := json.Unmarshal(out, &top); err != nil {
+		t.Fatal(err)
+	}
+	if string(top["model"]) != `"opus"` || string(top["permissions"]) == "" {
+		t.Errorf("other settings lost: %s", out)
+	}
+}
+
+func TestMergeHooksRejectsBadSettings(t *testing.T) {
+	if _, err := MergeHooks([]byte("{not json"), Hooks{}, nil); err == nil {
+		t.Error("merged into invalid settings, want an error")
+	}
+}
+
+func TestHookPaths(t *testing.T) {
+	tests := []struct {
+		path       string
+		settings   bool
+		script     bool
+		scriptPath string
+	}{
+		{".claude/settings.json", true, false, "settings.json"},
+		{"pkg/.claude/settings.local.json", true, false, "settings.local.json"},
+		{"settings.json", false, false, "settings.json"},
+		{".vscode/settings.json", false, false, "settings.json"},
+		{".claude/hooks/format.sh", false, true, "format.sh"},
+		{"tools/.claude/hooks/lint/run.py", false, true, "lint/run.py"},
+		{".claude/agents/reviewer.md", false, false, "reviewer.md"},
+	}
+
+	for _, tt := range tests {
+		if got := IsSettingsFile(tt.path); got != tt.settings {
+			t.Errorf("IsSettingsFile(%q) = %v, want %v", tt.path, got, tt.settings)
+		}
+		if got := IsHookScript(tt.path); got != tt.script {
+			t.Errorf("IsHookScript(%q) = %v, want %v", tt.path, got, tt.script)
+		}
+		if got := HookScriptPath(tt.path); got != tt.scriptPath {
+			t.Errorf("HookScriptPath(%q) = %q, want %q", tt.path, got, tt.scriptPath)
+		}
+	}
+}
+
+func TestParseHooks(t *testing.T) {
+	hooks, err := ParseHooks([]byte(`{"model": "opus", "hooks": {"Stop": [{"hooks": [{"type": "command", "command": "notify"}]}], "PreToolUse": []}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := hooks.Events(), []string{"PreToolUse", "Stop"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+
+	hooks, err = ParseHooks([]byte(`{"model": "opus"}`))
+	if err != nil || len(hooks) != 0 {
+		t.Errorf("settings without hooks = %v, %v; want none", hooks, err)
+	}
+	if _, err := ParseHooks([]byte("{not json")); err == nil {
+		t.Error("parsed invalid settings, want an error")
+	}
+}
@@ -0,0 +1,295 @@
+package github
+
+import (
+	"path"
+	"strings"
+)
+
+// InstallStrategy is how a downloaded artifact is put into place
+type InstallStrategy int
+
+const (
+	InstallFile       InstallStrategy = iota // Write the file into the install directory
+	InstallSkill                             // Copy the file's whole directory
+	InstallHooks                             // Merge hooks into settings.json; scripts go under hooks/, executable
+	InstallMCP                               // Merge the chosen server into the MCP config
+	InstallAppend                            // Append the picked sections to CLAUDE.md
+	InstallStatusline                        // Write the script executable and point settings.json at it
+)
+
+// Kind describes one kind of artifact: where it lives in a repository, how to
+// search for it and where and how it is installed
+type Kind struct {
+	Mode SearchMode
+	Slug string // Used on the command line, e.g. --mode agents
+	Name string // Shown in the mode indicator
+	Noun string // Plural, for result counts
+
+	// Globs are the paths the kind lives at, matched anywhere in a repository.
+	// * matches within a path segment and ** any number of directories.
+	Globs []string
+	Ext   string // Extension every match must have; empty when the globs name the file
+	Query string // Search qualifiers; defaults to a path: restriction on the first glob
+
+	ByName      bool // Keywords are matched against names rather than file contents
+	NamedByDir  bool // Named after the directory holding the file rather than the file
+	Frontmatter bool // Files open with YAML frontmatter describing them
+	Sections    bool // Installs take the picked sections of a file rather than all of it
+
+	GlobalDir  string // Install directory relative to the home directory
+	ProjectDir string // Install directory relative to the working directory
+	Install    InstallStrategy
+}
+
+// Kinds is the registry of artifact kinds, indexed by SearchMode
+var Kinds = []Kind{
+	{
+		Mode:        ModeAgents,
+		Slug:        "agents",
+		Name:        "Agents",
+		Noun:        "agent files",
+		Globs:       []string{".claude/agents/**"},
+		Ext:         ".md",
+		ByName:      true,
+		GlobalDir:   ".claude/agents",
+		ProjectDir:  ".claude/agents",
+		Frontmatter: true,
+		Install:     InstallFile,
+	},
+	{
+		Mode:        ModeCommands,
+		Slug:        "commands",
+		Name:        "Commands",
+		Noun:        "command files",
+		Globs:       []string{".claude/commands/**"},
+		Ext:         ".md",
+		ByName:      true,
+		GlobalDir:   ".claude/commands",
+		ProjectDir:  ".claude/commands",
+		Frontmatter: true,
+		Install:     InstallFile,
+	},
+	{
+		Mode:  ModeSkills,
+		Slug:  "skills",
+		Name:  "Skills",
+		Noun:  "skills",
+		Globs: []string{".claude/skills/**/" + skillFile},
+		// Every skill has exactly one SKILL.md, so it stands in for the directory
+		Query:       "path:/.claude/skills/ filename:" + skillFile,
+		ByName:      true,
+		NamedByDir:  true,
+		GlobalDir:   ".claude/skills",
+		ProjectDir:  ".claude/skills",
+		Frontmatter: true,
+		Install:     InstallSkill,
+	},
+	{
+		Mode:  ModeHooks,
+		Slug:  "hooks",
+		Name:  "Hooks",
+		Noun:  "hook files",
+		Globs: []string{".claude/settings.json", ".claude/settings.local.json", ".claude/hooks/**"},
+		// Matches "hooks" in settings.json contents and in .claude/hooks/ paths
+		Query:      "path:/.claude/ hooks",
+		GlobalDir:  ".claude",
+		ProjectDir: ".claude",
+		Install:    InstallHooks,
+	},
+	{
+		Mode:  ModeMCP,
+		Slug:  "mcp",
+		Name:  "MCP",
+		Noun:  "MCP servers",
+		Globs: []string{mcpConfigFile, ".claude/settings.json"},
+		// .mcp.json lives at the repository root and settings under .claude/,
+		// so match on the block name rather than a path
+		Query:      "mcpServers extension:json",
+		GlobalDir:  "", // User-scoped servers live in ~/.claude.json
+		ProjectDir: "",
+		Install:    InstallMCP,
+	},
+	{
+		Mode:       ModeMemory,
+		Slug:       "claude-md",
+		Name:       "CLAUDE.md",
+		Noun:       "CLAUDE.md files",
+		Globs:      []string{memoryFile},
+		Query:      "filename:" + memoryFile,
+		Sections:   true,
+		GlobalDir:  ".claude",
+		ProjectDir: "",
+		Install:    InstallAppend,
+	},
+	{
+		Mode:        ModeOutputStyles,
+		Slug:        "output-styles",
+		Name:        "Output styles",
+		Noun:        "output styles",
+		Globs:       []string{".claude/output-styles/**"},
+		Ext:         ".md",
+		ByName:      true,
+		GlobalDir:   ".claude/output-styles",
+		ProjectDir:  ".claude/output-styles",
+		Frontmatter: true,
+		Install:     InstallFile,
+	},
+	{
+		Mode:  ModeStatusline,
+		Slug:  "statusline",
+		Name:  "Statusline",
+		Noun:  "statusline scripts",
+		Globs: []string{".claude/statusline*", ".claude/statusline/**"},
+		// Scripts are usually just "statusline.sh", so match on what they show
+		Query:      "path:/.claude/ statusline",
+		GlobalDir:  ".claude",
+		ProjectDir: ".claude",
+		Install:    InstallStatusline,
+	},
+}
+
+// KindFor returns the registry entry for a search mode
+func KindFor(mode SearchMode) Kind {
+	if int(mode) < 0 || int(mode) >= len(Kinds) {
+		return Kinds[ModeAgents]
+	}
+	return Kinds[mode]
+}
+
+// KindBySlug looks up a kind by its command line name
+func KindBySlug(slug string) (Kind, bool) {
+	for _, k := range Kinds {
+		if k.Slug == slug {
+			return k, true
+		}
+	}
+	return Kind{}, false
+}
+
+// NextMode returns the mode after mode, wrapping around
+func NextMode(mode SearchMode) SearchMode {
+	return SearchMode((int(mode) + 1) % len(Kinds))
+}
+
+// DefaultPath is where the kind usually lives in a repository: the first glob
+// when it names a single file, or the directory it matches under
+func (k Kind) DefaultPath() string {
+	glob := k.Globs[0]
+	if !strings.ContainsAny(glob, "*?[") {
+		return glob
+	}
+	return strings.TrimSuffix(globDir(glob), "/")
+}
+
+// Matches reports whether a repository path is an artifact of this kind
+func (k Kind) Matches(p string) bool {
+	if k.Ext != "" && !strings.HasSuffix(p, k.Ext) {
+		return false
+	}
+	for _, glob := range k.Globs {
+		if matchGlob(glob, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// query is the search restriction for the kind
+func (k Kind) query() string {
+	if k.Query != "" {
+		return k.Query
+	}
+	return "path:/" + globDir(k.Globs[0])
+}
+
+// name is what keywords are matched against for a path of this kind
+func (k Kind) name(p string) string {
+	if k.NamedByDir {
+		return path.Base(path.Dir(p))
+	}
+	return path.Base(p)
+}
+
+// relPath trims a path of this kind to the part below its directory. Kinds named
+// by their directory show the directory instead of the file.
+func (k Kind) relPath(p string) (string, bool) {
+	for _, glob := range k.Globs {
+		dir := globDir(glob)
+		if dir == "" || !matchGlob(glob, p) {
+			continue
+		}
+		idx := strings.Index("/"+p, "/"+dir)
+		if idx < 0 {
+			continue
+		}
+		rel := p[idx+len(dir):]
+		if k.NamedByDir {
+			rel = path.Dir(rel) + "/"
+		}
+		return rel, true
+	}
+	return "", false
+}
+
+// kindOf returns the first kind whose globs match p
+func kindOf(p string) (Kind, bool) {
+	for _, k := range Kinds {
+		if k.Matches(p) {
+			return k, true
+		}
+	}
+	return Kind{}, false
+}
+
+// globDir is the literal directory prefix of a glob, with a trailing slash,
+// or "" when the glob starts with a file name or wildcard
+func globDir(glob string) string {
+	segments := strings.Split(glob, "/")
+	var dir []string
+	for _, s := range segments[:len(segments)-1] {
+		if strings.ContainsAny(s, "*?[") {
+			break
+		}
+		dir = append(dir, s)
+	}
+	if len(dir) == 0 {
+		return ""
+	}
+	return strings.Join(dir, "/") + "/"
+}
+
+// matchGlob reports whether glob matches p or a trailing part of p, so
+// ".claude/agents/**" matches agents in nested packages too
+func matchGlob(glob, p string) bool {
+	pattern := strings.Split(glob, "/")
+	segments := strings.Split(p, "/")
+	for start := range segments {
+		if matchSegments(pattern, segments[start:]) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchSegments matches path segments against glob segments, where ** spans
+// any number of segments including none
+func matchSegments(pattern, segments []string) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchSegments(pattern[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segments) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], segments[0]); !ok {
+		return false
+	}
+	return matchSegments(pattern[1:], segments[1:])
+}
@@ -0,0 +1,112 @@
+package github
+
+import "testing"
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		glob string
+		path string
+		want bool
+	}{
+		{".claude/agents/**", ".claude/agents/reviewer.md", true},
+		{".claude/agents/**", ".claude/agents/team/reviewer.md", true},
+		{".claude/agents/**", "packages/web/.claude/agents/reviewer.md", true},
+		{".claude/agents/**", ".claude/commands/reviewer.md", false},
+		{".claude/agents/**", "claude/agents/reviewer.md", false},
+		{".claude/skills/**/SKILL.md", ".claude/skills/pdf/SKILL.md", true},
+		{".claude/skills/**/SKILL.md", ".claude/skills/docs/pdf/SKILL.md", true},
+		{".claude/skills/**/SKILL.md", ".claude/skills/pdf/README.md", false},
+		{".claude/statusline*", ".claude/statusline.sh", true},
+		{".claude/statusline*", ".claude/statusline/run.sh", false},
+		{"CLAUDE.md", "CLAUDE.md", true},
+		{"CLAUDE.md", "docs/CLAUDE.md", true},
+		{"CLAUDE.md", "docs/NOT-CLAUDE.md", false},
+	}
+
+	for _, tt := range tests {
+		if got := matchGlob(tt.glob, tt.path); got != tt.want {
+			t.Errorf("matchGlob(%q, %q) = %v, want %v", tt.glob, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestKindRelPath(t *testing.T) {
+	tests := []struct {
+		mode   SearchMode
+		path   string
+		want   string
+		wantOK bool
+	}{
+		{ModeAgents, ".claude/agents/reviewer.md", "reviewer.md", true},
+		{ModeAgents, "web/.claude/agents/team/reviewer.md", "team/reviewer.md", true},
+		{ModeSkills, ".claude/skills/pdf/SKILL.md", "pdf/", true},
+		{ModeSkills, "x/.claude/skills/docs/pdf/SKILL.md", "docs/pdf/", true},
+		{ModeHooks, ".claude/hooks/lint/run.sh", "lint/run.sh", true},
+		{ModeMemory, "CLAUDE.md", "", false},
+		{ModeAgents, ".claude/commands/reviewer.md", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := KindFor(tt.mode).relPath(tt.path)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("%s relPath(%q) = %q, %v; want %q, %v", KindFor(tt.mode).Slug, tt.path, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestKindMatches(t *testing.T) {
+	tests := []struct {
+		mode SearchMode
+		path string
+		want bool
+	}{
+		{ModeAgents, ".claude/agents/reviewer.md", true},
+		{ModeAgents, ".claude/agents/notes.txt", false},
+		{ModeOutputStyles, ".claude/output-styles/terse.md", true},
+		{ModeStatusline, ".claude/statusline.sh", true},
+		{ModeStatusline, ".claude/statusline/powerline.py", true},
+		{ModeMCP, ".mcp.json", true},
+		{ModeMCP, "config/.mcp.json", true},
+	}
+
+	for _, tt := range tests {
+		if got := KindFor(tt.mode).Matches(tt.path); got != tt.want {
+			t.Errorf("%s Matches(%q) = %v, want %v", KindFor(tt.mode).Slug, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestKindsIndexedByMode(t *testing.T) {
+	for i, kind := range Kinds {
+		if kind.Mode != SearchMode(i) {
+			t.Errorf("Kinds[%d] is %s with Mode %d; KindFor relies on Kinds[i].Mode == i", i, kind.Slug, kind.Mode)
+		}
+	}
+}
+
+func TestSkillDirs(t *testing.T) {
+	tests := []struct {
+		path     string
+		skillDir string // SkillDir of path
+		isDir    bool   // IsSkillDir of path
+	}{
+		{".claude/skills/pdf/SKILL.md", ".claude/skills/pdf", false},
+		{"x/.claude/skills/docs/pdf/SKILL.md", "x/.claude/skills/docs/pdf", false},
+		{".claude/skills/pdf/README.md", "", false},
+		{".claude/agents/SKILL.md", "", false},
+		{".claude/skills/pdf", "", true},
+		{"pkg/.claude/skills/pdf", "", true},
+		{".claude/skills/pdf/scripts", "", false},
+		{"my.claude/skills/pdf", "", false},
+		{".claude/skills", "", false},
+	}
+
+	for _, tt := range tests {
+		if got := SkillDir(tt.path); got != tt.skillDir {
+			t.Errorf("SkillDir(%q) = %q, want %q", tt.path, got, tt.skillDir)
+		}
+		if got := IsSkillDir(tt.path); got != tt.isDir {
+			t.Errorf("IsSkillDir(%q) = %v, want %v", tt.path, got, tt.isDir)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// mcpConfigFile is the project-scoped MCP server configuration
+const mcpConfigFile = ".mcp.json"
+
+// MCPServers is the mcpServers block of an .mcp.json or Claude settings file,
+// keyed by server name. Server definitions are kept verbatim.
+type MCPServers map[string]json.RawMessage
+
+// ParseMCPServers extracts the mcpServers block from a config file
+func ParseMCPServers(data []byte) (MCPServers, error) {
+	var config struct {
+		MCPServers MCPServers `json:"mcpServers"`
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse MCP config: %w", err)
+	}
+	return config.MCPServers, nil
+}
+
+// Names lists the servers, sorted
+func (s MCPServers) Names() []string {
+	names := make([]string, 0, len(s))
+	for name := range s {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// MergeMCPServers adds servers to an existing config, keeping every other key.
+// A server with the same name as an existing one replaces it. Empty config starts
+// a new file.
+func MergeMCPServers(config []byte, servers MCPServers) ([]byte, error) {
+	top := make(map[string]json.RawMessage)
+	if len(strings.TrimSpace(string(config))) > 0 {
+		if err := json.Unmarshal(config, &top); err != nil {
+			return nil, fmt.Errorf("failed to parse MCP config: %w", err)
+		}
+	}
+
+	merged := make(MCPServers)
+	if raw, ok := top["mcpServers"]; ok {
+		if err := json.Unmarshal(raw, &merged); err != nil {
+			return nil, fmt.Errorf("failed to parse existing MCP servers: %w", err)
+		}
+	}
+	for name, server := range servers {
+		merged[name] = server
+	}
+
+	raw, err := json.Marshal(merged)
+	if err != nil {
+		return nil, err
+	}
+	top["mcpServers"] = raw
+
+	out, err := json.MarshalIndent(top, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
+
+// expandMCPServers fetches each config file in results and replaces it with one
+// result per server whose name or definition matches the keywords. Files that cannot
+// be fetched or define no servers are dropped, since there is nothing to select.
+// Results that already name a server (fixtures) pass through unchanged.
+func expandMCPServers(ctx context.Context, results []Result, keywords string, opts SearchOptions) []Result {
+	keywordList := strings.Fields(strings.ToLower(keywords))
+	expanded := make([][]Result, len(results))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, 5)
+
+	for i := range results {
+		if results[i].MCPServer != "" {
+			expanded[i] = []Result{results[i]}
+			continue
+		}
+		wg.Add(1)
+		go func(r Result, out *[]Result) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			content, err := DefaultClient.Download(ctx, r.URL)
+			if err != nil {
+				return
+			}
+			servers, err := ParseMCPServers([]byte(content))
+			if err != nil {
+				return
+			}
+			for _, name := range servers.Names() {
+				text := strings.ToLower(name + " " + string(servers[name]))
+				if !filenameMatches(text, keywordList, opts.MatchMode) {
+					continue
+				}
+				server := r
+				server.MCPServer = name
+				server.RelPath = relPathFor(r.Repo, r.Path) + " → " + name
+				*out = append(*out, server)
+			}
+		}(results[i], &expanded[i])
+	}
+	wg.Wait()
+
+	var servers []Result
+	for _, e := range expanded {
+		servers = append(servers, e...)
+	}
+	return servers
+}
@@ -0,0 +1,83 @@
+package github
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestMergeMCPServers(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		servers MCPServers
+		want    map[string]string // Server definitions, compacted
+		keep    []string          // Other top-level keys that must survive
+	}{
+		{
+			name:    "starts a new file",
+			servers: MCPServers{"fs": json.RawMessage(`{"command": "fs"}`)},
+			want:    map[string]string{"fs": `{"command":"fs"}`},
+		},
+		{
+			name:    "adds to the existing servers",
+			config:  `{"mcpServers": {"git": {"command": "git"}}}`,
+			servers: MCPServers{"fs": json.RawMessage(`{"command": "fs"}`)},
+			want:    map[string]string{"git": `{"command":"git"}`, "fs": `{"command":"fs"}`},
+		},
+		{
+			name:    "replaces a server with the same name",
+			config:  `{"mcpServers": {"fs": {"command": "old"}}}`,
+			servers: MCPServers{"fs": json.RawMessage(`{"command": "new"}`)},
+			want:    map[string]string{"fs": `{"command":"new"}`},
+		},
+		{
+			name:    "keeps every other key",
+			config:  `{"theme": "dark", "projects": {"a": {}}, "mcpServers": {}}`,
+			servers: MCPServers{"fs": json.RawMessage(`{"command": "fs"}`)},
+			want:    map[string]string{"fs": `{"command":"fs"}`},
+			keep:    []string{"theme", "projects"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := MergeMCPServers([]byte(tt.config), tt.servers)
+			if err != nil {
+				t.Fatal(err)
+			}
+			servers, err := ParseMCPServers(out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make(map[string]string)
+			for name, raw := range servers {
+				var v any
+				if err := json.Unmarshal(raw, &v); err != nil {
+					t.Fatal(err)
+				}
+				compact, _ := json.Marshal(v)
+				got[name] = string(compact)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("servers = %v, want %v", got, tt.want)
+			}
+
+			var top map[string]json.RawMessage
+			if err := json.Unmarshal(out, &top); err != nil {
+				t.Fatal(err)
+			}
+			for _, key := range tt.keep {
+				if _, ok := top[key]; !ok {
+					t.Errorf("%s was dropped", key)
+				}
+			}
+		})
+	}
+}
+
+func TestMergeMCPServersRejectsBadConfig(t *testing.T) {
+	if _, err := MergeMCPServers([]byte("{not json"), MCPServers{}); err == nil {
+		t.Error("merged into an invalid config, want an error")
+	}
+}
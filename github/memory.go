@@ -0,0 +1,67 @@
+package github
+
+import (
+	"path"
+	"strings"
+)
+
+// memoryFile is the instructions file Claude reads at a repository root or subdirectory
+const memoryFile = "CLAUDE.md"
+
+// IsMemoryFile reports whether p is a CLAUDE.md
+func IsMemoryFile(p string) bool {
+	return path.Base(p) == memoryFile
+}
+
+// Section is one heading of a markdown file and everything up to the next one
+type Section struct {
+	Heading string // Heading text without the leading #s; empty for text before the first heading
+	Level   int    // 1 for #, 2 for ##; 0 for the preamble
+	Text    string // The heading line and body, verbatim
+}
+
+// SplitSections splits markdown on its # and ## headings. Deeper headings stay
+// with their parent so picking a section keeps its subsections, and headings
+// inside fenced code blocks are ignored. Blank preambles are dropped.
+func SplitSections(content string) []Section {
+	var sections []Section
+	current := Section{}
+	var body []string
+	inFence := false
+
+	flush := func() {
+		current.Text = strings.TrimRight(strings.Join(body, "\n"), "\n")
+		if current.Level > 0 || strings.TrimSpace(current.Text) != "" {
+			sections = append(sections, current)
+		}
+	}
+
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+		}
+
+		if level, heading := headingLevel(line); !inFence && level > 0 && level <= 2 {
+			flush()
+			current = Section{Heading: heading, Level: level}
+			body = nil
+		}
+		body = append(body, line)
+	}
+	flush()
+
+	return sections
+}
+
+// headingLevel parses an ATX heading line, returning 0 when line is not one
+func headingLevel(line string) (int, string) {
+	level := 0
+	for level < len(line) && line[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 || (level < len(line) && line[level] != ' ' && line[level] != '\t') {
+		return 0, ""
+	}
+	return level, strings.TrimSpace(strings.TrimRight(strings.TrimSpace(line[level:]), "#"))
+}
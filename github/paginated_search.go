@@ -1,175 +1,245 @@
 package github
 
 import (
+	"bufio"
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"net/http"
+	"net/textproto"
 	"os/exec"
+	"strconv"
 	"strings"
 	"time"
 )
 
-// PaginatedSearchByFilename implements rate-limited search with filename filtering
-func PaginatedSearchByFilename(keywords string, opts SearchOptions) []Result {
-	if opts.Limit == 0 {
+// PaginatedSearchByFilename implements rate-limited search with filename filtering,
+// passing each batch of results to emit as soon as it is available
+func PaginatedSearchByFilename(ctx context.Context, keywords string, opts SearchOptions, emit func([]Result)) error {
+	if opts.Limit <= 0 {
 		opts.Limit = 100
 	}
 
-	// First try using the filename flag for direct filename search
-	if keywords != "" {
-		results := searchWithFilenameFlag(keywords, opts)
+	// First try using the filename flag for direct filename search.
+	// It only helps kinds named by their file: skills always live in SKILL.md,
+	// and config and memory files are matched by what they contain.
+	if kind := KindFor(opts.SearchMode); keywords != "" && kind.ByName && !kind.NamedByDir {
+		results, err := searchWithFilenameFlag(ctx, keywords, opts)
+		if err != nil && !isRecoverable(err) {
+			return err
+		}
 		if len(results) > 0 {
-			return results
+			emit(results)
+			return nil
 		}
 	}
 
 	// Fallback to content search with filename filtering
-	return searchWithRateLimit(keywords, opts)
+	return searchWithRateLimit(ctx, keywords, opts, emit)
+}
+
+// isRecoverable reports whether a failed search is worth retrying another way.
+// Missing tooling, bad credentials, exhausted limits and cancellation are not.
+func isRecoverable(err error) bool {
+	return !errors.Is(err, ErrBackendMissing) &&
+		!errors.Is(err, ErrUnauthenticated) &&
+		!errors.Is(err, ErrRateLimited) &&
+		!errors.Is(err, context.Canceled) &&
+		!errors.Is(err, context.DeadlineExceeded)
 }
 
-// searchWithFilenameFlag uses GitHub CLI's --filename flag for direct filename matching
-func searchWithFilenameFlag(keywords string, opts SearchOptions) []Result {
+// searchWithFilenameFlag searches with a filename: qualifier for direct filename matching
+func searchWithFilenameFlag(ctx context.Context, keywords string, opts SearchOptions) ([]Result, error) {
 	basePath := buildBasePath(opts)
 
 	// Create filename pattern - search for files containing the keyword in filename
-	filenamePattern := fmt.Sprintf("*%s*", keywords)
+	query := fmt.Sprintf("%s filename:*%s* in:path", basePath, keywords)
 
-	// Build the search command with filename filtering
-	cmd := exec.Command("gh", "search", "code",
-		"--filename", filenamePattern,
-		"--match", "path",
-		basePath,
-		"--limit", fmt.Sprintf("%d", opts.Limit),
-		"--json", "repository,path,url")
-
-	output, err := cmd.Output()
+	results, _, err := searchBatchWithRetry(ctx, query, min(opts.Limit, 100), 1, opts)
 	if err != nil {
 		log.Printf("GitHub filename search failed: %v", err)
-		return []Result{}
+		return nil, err
 	}
-
-	return parseSearchResults(output)
+	return results, nil
 }
 
 // searchWithRateLimit implements rate-limited content search with retries
-func searchWithRateLimit(keywords string, opts SearchOptions) []Result {
-	var allResults []Result
+func searchWithRateLimit(ctx context.Context, keywords string, opts SearchOptions, emit func([]Result)) error {
 	remainingLimit := opts.Limit
 	batchSize := 30 // Conservative batch size to avoid rate limits
+	query := buildContentSearchQuery(keywords, opts)
 
-	for remainingLimit > 0 {
-		currentBatch := batchSize
-		if currentBatch > remainingLimit {
-			currentBatch = remainingLimit
+	// Read the budget once; every search response's headers keep it current after that
+	if !rates.get(codeSearchResource).Known() {
+		if err := refreshRateLimitsGH(ctx); err != nil && ctx.Err() != nil {
+			return ctx.Err()
 		}
+	}
 
-		// Search for this batch
-		query := buildContentSearchQuery(keywords, opts)
-		results := searchBatchWithRetry(query, currentBatch)
+	for page := 1; remainingLimit > 0; page++ {
+		// Hold off until the code search budget allows another request
+		if err := rates.wait(ctx, codeSearchResource); err != nil {
+			return err
+		}
 
-		if len(results) == 0 {
-			break // No more results
+		// Search for this batch
+		results, more, err := searchBatchWithRetry(ctx, query, batchSize, page, opts)
+		if err != nil {
+			return err
 		}
 
 		// Filter by filename
 		filtered := filterByFilename(results, keywords, opts)
-		allResults = append(allResults, filtered...)
-
-		remainingLimit -= len(filtered)
-
-		// Rate limiting: wait between requests
-		if remainingLimit > 0 {
-			log.Printf("Waiting 2 seconds to avoid rate limit...")
-			time.Sleep(2 * time.Second)
+		if len(filtered) > remainingLimit {
+			filtered = filtered[:remainingLimit]
 		}
-
-		// If we got fewer results than requested, we've reached the end
-		if len(results) < currentBatch {
-			break
+		if len(filtered) > 0 {
+			emit(filtered)
 		}
-	}
 
-	// Fetch stars for all results
-	if len(allResults) > 0 {
-		repos := extractUniqueRepos(allResults)
-		stars := fetchStarsParallel(repos)
+		remainingLimit -= len(filtered)
 
-		for i := range allResults {
-			allResults[i].Stars = stars[allResults[i].Repo]
+		if !more {
+			break // No more results
 		}
 	}
 
-	return allResults
+	return nil
 }
 
-// searchBatchWithRetry searches a single batch with retry logic for rate limits
-func searchBatchWithRetry(query string, limit int) []Result {
+// searchBatchWithRetry fetches one page of code search results, retrying when a
+// rate limit is hit. It also reports whether GitHub has another page.
+func searchBatchWithRetry(ctx context.Context, query string, perPage, page int, opts SearchOptions) ([]Result, bool, error) {
 	maxRetries := 3
 	baseDelay := 10 * time.Second
 
 	for attempt := 0; attempt < maxRetries; attempt++ {
-		cmd := exec.Command("gh", "search", "code", query,
-			"--limit", fmt.Sprintf("%d", limit),
-			"--json", "repository,path,url")
-
-		output, err := cmd.Output()
+		header, body, err := ghAPI(ctx, "search/code", "-X", "GET",
+			"-f", "q="+query,
+			"-f", fmt.Sprintf("per_page=%d", perPage),
+			"-f", fmt.Sprintf("page=%d", page))
 		if err != nil {
-			if strings.Contains(err.Error(), "rate limit") {
-				waitTime := baseDelay * time.Duration(1<<attempt) // Exponential backoff
-				log.Printf("Rate limit hit, waiting %v before retry %d/%d", waitTime, attempt+1, maxRetries)
-				time.Sleep(waitTime)
+			if errors.Is(err, ErrRateLimited) {
+				// Wait for the reset or Retry-After the response gave, else back off exponentially
+				waitTime := baseDelay * time.Duration(1<<attempt)
+				log.Printf("Rate limit hit, waiting before retry %d/%d", attempt+1, maxRetries)
+				if err := waitRetry(ctx, err, waitTime); err != nil {
+					return nil, false, err
+				}
 				continue
 			}
 			log.Printf("Search failed: %v", err)
-			return []Result{}
+			return nil, false, err
 		}
 
-		return parseSearchResults(output)
+		results, err := parseSearchResults(body, opts)
+		return results, nextPageURL(header.Get("Link")) != "", err
 	}
 
 	log.Printf("Max retries exceeded for query: %s", query)
-	return []Result{}
+	return nil, false, &RateLimitError{Reset: rates.get(codeSearchResource).Reset}
 }
 
-// parseSearchResults parses GitHub CLI JSON output into Results
-func parseSearchResults(output []byte) []Result {
-	var rawResults []struct {
-		Repository struct {
-			NameWithOwner string `json:"nameWithOwner"`
-		} `json:"repository"`
-		Path string `json:"path"`
-		URL  string `json:"url"`
+// ghAPI runs `gh api -i` and returns the response headers and body. The headers
+// keep rates current, and failed requests are classified by their status and
+// headers the same way the REST client classifies them.
+func ghAPI(ctx context.Context, path string, args ...string) (http.Header, []byte, error) {
+	output, err := exec.CommandContext(ctx, "gh", append([]string{"api", "-i", path}, args...)...).Output()
+	if ctx.Err() != nil {
+		return nil, nil, ctx.Err()
 	}
 
-	if err := json.Unmarshal(output, &rawResults); err != nil {
-		log.Printf("JSON parse error: %v", err)
-		return []Result{}
+	resp, body, ok := parseGHResponse(output)
+	if ok {
+		rates.observe(resp.Header)
+	}
+	switch {
+	case err != nil && ok:
+		return nil, nil, httpError(path, resp)
+	case err != nil:
+		return nil, nil, ghError(err)
+	case !ok:
+		return nil, nil, fmt.Errorf("gh api %s: unexpected output", path)
+	}
+	return resp.Header, body, nil
+}
+
+// parseGHResponse splits the output of `gh api -i` into the response's status line
+// and headers, and its body
+func parseGHResponse(output []byte) (*http.Response, []byte, bool) {
+	r := textproto.NewReader(bufio.NewReader(bytes.NewReader(output)))
+	line, err := r.ReadLine()
+	if err != nil {
+		return nil, nil, false
+	}
+	proto, status, ok := strings.Cut(line, " ")
+	if !ok || !strings.HasPrefix(proto, "HTTP/") {
+		return nil, nil, false
+	}
+	code, _, _ := strings.Cut(status, " ")
+	statusCode, err := strconv.Atoi(code)
+	if err != nil {
+		return nil, nil, false
+	}
+
+	header, err := r.ReadMIMEHeader()
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, nil, false
+	}
+	body, err := io.ReadAll(r.R)
+	if err != nil {
+		return nil, nil, false
+	}
+	return &http.Response{Status: status, StatusCode: statusCode, Header: http.Header(header)}, body, true
+}
+
+// parseSearchResults parses a REST code search response into Results
+func parseSearchResults(output []byte, opts SearchOptions) ([]Result, error) {
+	var body struct {
+		Items []struct {
+			Path       string `json:"path"`
+			HTMLURL    string `json:"html_url"`
+			Repository struct {
+				FullName string `json:"full_name"`
+			} `json:"repository"`
+		} `json:"items"`
+	}
+
+	if err := json.Unmarshal(output, &body); err != nil {
+		return nil, fmt.Errorf("failed to parse search results: %w", err)
 	}
 
 	var results []Result
-	for _, r := range rawResults {
-		// Only include .md files
-		if !strings.HasSuffix(r.Path, ".md") {
+	for _, item := range body.Items {
+		// Only include files this mode can install
+		if !wantedFile(item.Path, opts) {
 			continue
 		}
 
-		// Build relative path
-		relPath := r.Repository.NameWithOwner + "/" + r.Path
-		if idx := strings.Index(r.Path, ".claude/agents/"); idx >= 0 {
-			relPath = r.Repository.NameWithOwner + "/" + r.Path[idx+15:]
-		} else if idx := strings.Index(r.Path, ".claude/commands/"); idx >= 0 {
-			relPath = r.Repository.NameWithOwner + "/" + r.Path[idx+16:]
-		}
-
 		results = append(results, Result{
-			Repo:     r.Repository.NameWithOwner,
-			Path:     r.Path,
-			URL:      r.URL,
-			Stars:    0, // Will be filled later
-			RelPath:  relPath,
-			Selected: false,
+			Repo:    item.Repository.FullName,
+			Path:    item.Path,
+			URL:     item.HTMLURL,
+			RelPath: relPathFor(item.Repository.FullName, item.Path),
 		})
 	}
 
-	return results
-}
\ No newline at end of file
+	return results, nil
+}
+
+// sleepContext waits for d or until ctx is cancelled, reporting whether the full wait elapsed
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,204 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// maxRateLimitWait caps how long a search will block waiting for a limit to reset
+// before giving up with a RateLimitError
+const maxRateLimitWait = 90 * time.Second
+
+// codeSearchResource is the budget GitHub charges /search/code against
+const codeSearchResource = "code_search"
+
+// RateLimit is the budget for one API resource
+type RateLimit struct {
+	Limit     int
+	Remaining int
+	Reset     time.Time
+}
+
+// Known reports whether the limit has been observed yet
+func (r RateLimit) Known() bool {
+	return r.Limit > 0
+}
+
+// RateLimits groups the budgets the search layer cares about
+type RateLimits struct {
+	Core    RateLimit
+	Search  RateLimit // Code search budget
+	GraphQL RateLimit
+}
+
+// rateTracker remembers the latest budget seen for each resource
+type rateTracker struct {
+	mu     sync.Mutex
+	limits map[string]RateLimit
+}
+
+// rates is updated from every API response and /rate_limit call
+var rates = &rateTracker{limits: make(map[string]RateLimit)}
+
+// CurrentRateLimits returns the most recently observed budgets
+func CurrentRateLimits() RateLimits {
+	search := rates.get(codeSearchResource)
+	if !search.Known() {
+		search = rates.get("search")
+	}
+	return RateLimits{
+		Core:    rates.get("core"),
+		Search:  search,
+		GraphQL: rates.get("graphql"),
+	}
+}
+
+// RefreshRateLimits asks the configured backend for the current budgets.
+// Checking the rate limit does not count against it.
+func RefreshRateLimits(ctx context.Context) (RateLimits, error) {
+	if rl, ok := defaultSearcher.(interface {
+		RefreshRateLimits(ctx context.Context) error
+	}); ok {
+		if err := rl.RefreshRateLimits(ctx); err != nil {
+			return CurrentRateLimits(), err
+		}
+	}
+	return CurrentRateLimits(), nil
+}
+
+func (t *rateTracker) get(resource string) RateLimit {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.limits[resource]
+}
+
+func (t *rateTracker) set(resource string, rl RateLimit) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.limits[resource] = rl
+}
+
+// observe records the X-RateLimit-* headers of an API response
+func (t *rateTracker) observe(h http.Header) {
+	limit, err := strconv.Atoi(h.Get("X-RateLimit-Limit"))
+	if err != nil {
+		return
+	}
+	remaining, _ := strconv.Atoi(h.Get("X-RateLimit-Remaining"))
+	resource := h.Get("X-RateLimit-Resource")
+	if resource == "" {
+		resource = "core"
+	}
+	t.set(resource, RateLimit{
+		Limit:     limit,
+		Remaining: remaining,
+		Reset:     resetTime(h),
+	})
+}
+
+// observeJSON records every resource in a /rate_limit response body
+func (t *rateTracker) observeJSON(data []byte) error {
+	var body struct {
+		Resources map[string]struct {
+			Limit     int   `json:"limit"`
+			Remaining int   `json:"remaining"`
+			Reset     int64 `json:"reset"`
+		} `json:"resources"`
+	}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return fmt.Errorf("failed to parse rate limit: %w", err)
+	}
+	for resource, r := range body.Resources {
+		t.set(resource, RateLimit{
+			Limit:     r.Limit,
+			Remaining: r.Remaining,
+			Reset:     time.Unix(r.Reset, 0),
+		})
+	}
+	return nil
+}
+
+// wait blocks until the resource has budget left. It returns immediately when the
+// budget is unknown or not exhausted, and fails with a RateLimitError rather than
+// wait longer than maxRateLimitWait.
+func (t *rateTracker) wait(ctx context.Context, resource string) error {
+	rl := t.get(resource)
+	if !rl.Known() || rl.Remaining > 0 {
+		return nil
+	}
+
+	wait := time.Until(rl.Reset) + time.Second
+	if wait <= time.Second {
+		return nil
+	}
+	if wait > maxRateLimitWait {
+		return &RateLimitError{Reset: rl.Reset}
+	}
+	if !sleepContext(ctx, wait) {
+		return ctx.Err()
+	}
+	return nil
+}
+
+// waitRetry blocks until a rate-limited request may be retried, using the reset or
+// Retry-After time carried by err and falling back to fallback when it has none.
+// Like wait, it gives up with err rather than wait longer than maxRateLimitWait.
+func waitRetry(ctx context.Context, err error, fallback time.Duration) error {
+	wait := fallback
+	var rle *RateLimitError
+	if errors.As(err, &rle) && !rle.Reset.IsZero() {
+		wait = rle.RetryAfter()
+	}
+	if wait > maxRateLimitWait {
+		return err
+	}
+	if wait > 0 && !sleepContext(ctx, wait) {
+		return ctx.Err()
+	}
+	return nil
+}
+
+// RefreshRateLimits loads the current budgets from /rate_limit
+func (c *Client) RefreshRateLimits(ctx context.Context) error {
+	resp, err := c.get(ctx, "/rate_limit", "application/vnd.github+json")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	return rates.observeJSON(data)
+}
+
+// RefreshRateLimits loads the current budgets through the REST client
+func (s *HTTPSearcher) RefreshRateLimits(ctx context.Context) error {
+	return s.Client.RefreshRateLimits(ctx)
+}
+
+// RefreshRateLimits loads the current budgets through `gh api rate_limit`
+func (GHSearcher) RefreshRateLimits(ctx context.Context) error {
+	return refreshRateLimitsGH(ctx)
+}
+
+// refreshRateLimitsGH loads the current budgets through `gh api rate_limit`
+func refreshRateLimitsGH(ctx context.Context) error {
+	output, err := exec.CommandContext(ctx, "gh", "api", "rate_limit").Output()
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return ghError(err)
+	}
+	return rates.observeJSON(output)
+}
@@ -0,0 +1,146 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRateTrackerObserve(t *testing.T) {
+	reset := time.Unix(1767225600, 0)
+	epoch := strconv.FormatInt(reset.Unix(), 10)
+
+	tests := []struct {
+		name     string
+		header   http.Header
+		resource string
+		want     RateLimit
+	}{
+		{
+			name: "code search",
+			header: http.Header{
+				"X-Ratelimit-Limit":     {"10"},
+				"X-Ratelimit-Remaining": {"4"},
+				"X-Ratelimit-Reset":     {epoch},
+				"X-Ratelimit-Resource":  {"code_search"},
+			},
+			resource: codeSearchResource,
+			want:     RateLimit{Limit: 10, Remaining: 4, Reset: reset},
+		},
+		{
+			name: "no resource means core",
+			header: http.Header{
+				"X-Ratelimit-Limit":     {"5000"},
+				"X-Ratelimit-Remaining": {"0"},
+				"X-Ratelimit-Reset":     {epoch},
+			},
+			resource: "core",
+			want:     RateLimit{Limit: 5000, Remaining: 0, Reset: reset},
+		},
+		{
+			name:     "no rate limit headers",
+			header:   http.Header{"Retry-After": {"30"}},
+			resource: "core",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := &rateTracker{limits: make(map[string]RateLimit)}
+			tracker.observe(tt.header)
+			if got := tracker.get(tt.resource); got != tt.want {
+				t.Errorf("%s = %+v, want %+v", tt.resource, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateTrackerObserveJSON(t *testing.T) {
+	tracker := &rateTracker{limits: make(map[string]RateLimit)}
+	data := `{"resources": {
+		"core": {"limit": 5000, "remaining": 4999, "reset": 1767225600},
+		"code_search": {"limit": 10, "remaining": 0, "reset": 1767225660}
+	}}`
+	if err := tracker.observeJSON([]byte(data)); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := tracker.get("core"), (RateLimit{5000, 4999, time.Unix(1767225600, 0)}); got != want {
+		t.Errorf("core = %+v, want %+v", got, want)
+	}
+	if got, want := tracker.get(codeSearchResource), (RateLimit{10, 0, time.Unix(1767225660, 0)}); got != want {
+		t.Errorf("code_search = %+v, want %+v", got, want)
+	}
+
+	if err := tracker.observeJSON([]byte("<html>")); err == nil {
+		t.Error("parsed a non-JSON body, want an error")
+	}
+}
+
+func TestRateTrackerWait(t *testing.T) {
+	tests := []struct {
+		name  string
+		limit RateLimit
+		ctx   func() context.Context
+		want  error
+	}{
+		{name: "unknown budget", limit: RateLimit{}},
+		{name: "budget left", limit: RateLimit{Limit: 10, Remaining: 1, Reset: time.Now().Add(time.Hour)}},
+		{name: "reset already passed", limit: RateLimit{Limit: 10, Reset: time.Now().Add(-time.Minute)}},
+		{name: "reset too far away", limit: RateLimit{Limit: 10, Reset: time.Now().Add(time.Hour)}, want: ErrRateLimited},
+		{
+			name:  "cancelled while waiting",
+			limit: RateLimit{Limit: 10, Reset: time.Now().Add(time.Minute)},
+			ctx: func() context.Context {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				return ctx
+			},
+			want: context.Canceled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := &rateTracker{limits: map[string]RateLimit{codeSearchResource: tt.limit}}
+			ctx := context.Background()
+			if tt.ctx != nil {
+				ctx = tt.ctx()
+			}
+			if err := tracker.wait(ctx, codeSearchResource); !errors.Is(err, tt.want) {
+				t.Errorf("wait = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestWaitRetry(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tooLong := &RateLimitError{Reset: time.Now().Add(time.Hour)}
+	if err := waitRetry(context.Background(), tooLong, 0); err != tooLong {
+		t.Errorf("reset an hour away: %v, want the rate limit error back", err)
+	}
+
+	// Retry-After from a secondary limit is waited out, unless the context ends first
+	secondary := httpError("/search/code", &http.Response{
+		StatusCode: http.StatusForbidden,
+		Status:     "403 Forbidden",
+		Header:     http.Header{"Retry-After": {"60"}, "X-Ratelimit-Remaining": {"7"}},
+	})
+	if err := waitRetry(cancelled, secondary, 0); !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled during Retry-After: %v, want context.Canceled", err)
+	}
+
+	// Without a reset time the fallback is used
+	start := time.Now()
+	if err := waitRetry(context.Background(), &RateLimitError{}, 10*time.Millisecond); err != nil {
+		t.Errorf("fallback wait: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 10*time.Millisecond {
+		t.Errorf("returned after %v, want the 10ms fallback", elapsed)
+	}
+}
@@ -0,0 +1,136 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+)
+
+// DefaultBranch returns the name of a repository's default branch
+func (c *Client) DefaultBranch(ctx context.Context, repo string) (string, error) {
+	var body struct {
+		DefaultBranch string `json:"default_branch"`
+	}
+	if err := c.getJSON(ctx, "/repos/"+repo, &body); err != nil {
+		return "", err
+	}
+	return body.DefaultBranch, nil
+}
+
+// ResolveCommit returns the commit SHA a branch, tag or SHA points at. An empty
+// ref means the default branch. Refs move, so the answer is never cached.
+func (c *Client) ResolveCommit(ctx context.Context, repo, ref string) (string, error) {
+	if IsCommitSHA(ref) {
+		return ref, nil
+	}
+	if ref == "" {
+		ref = "HEAD"
+	}
+
+	resp, err := c.get(ctx, "/repos/"+repo+"/commits/"+ref, "application/vnd.github.sha")
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+	sha := strings.TrimSpace(string(body))
+	if !IsCommitSHA(sha) {
+		return "", fmt.Errorf("unexpected commit %q for %s@%s", sha, repo, ref)
+	}
+	return sha, nil
+}
+
+// LatestCommit returns the newest commit on ref that touched path, a file or a
+// directory, or "" when none did. An empty ref means the default branch.
+func (c *Client) LatestCommit(ctx context.Context, repo, path, ref string) (string, error) {
+	params := url.Values{}
+	params.Set("path", path)
+	params.Set("per_page", "1")
+	if ref != "" {
+		params.Set("sha", ref)
+	}
+
+	var commits []struct {
+		SHA string `json:"sha"`
+	}
+	if err := c.getJSON(ctx, "/repos/"+repo+"/commits?"+params.Encode(), &commits); err != nil {
+		return "", err
+	}
+	if len(commits) == 0 {
+		return "", nil
+	}
+	return commits[0].SHA, nil
+}
+
+// IsCommitSHA reports whether ref is a full 40 character commit SHA
+func IsCommitSHA(ref string) bool {
+	if len(ref) != 40 {
+		return false
+	}
+	for _, c := range ref {
+		if !strings.ContainsRune("0123456789abcdef", c) {
+			return false
+		}
+	}
+	return true
+}
+
+// BlobURL is the web page for a file at ref on the server the client talks to
+func (c *Client) BlobURL(repo, ref, path string) string {
+	return fmt.Sprintf("%s/%s/blob/%s/%s", c.webRoot(), repo, ref, path)
+}
+
+// webRoot is the web address that goes with the API root: github.com for the
+// public API, the host of a GitHub Enterprise /api/v3 root, or else the root
+// itself
+func (c *Client) webRoot() string {
+	if c.BaseURL == defaultBaseURL {
+		return "https://github.com"
+	}
+	return strings.TrimSuffix(c.BaseURL, "/api/v3")
+}
+
+// ParseBlobURL splits a blob URL, https://<host>/owner/repo/blob/ref/path, into
+// the repository, ref and file path
+func ParseBlobURL(blobURL string) (repo, ref, path string, ok bool) {
+	u, err := url.Parse(blobURL)
+	if err != nil {
+		return "", "", "", false
+	}
+	parts := strings.SplitN(strings.TrimPrefix(u.Path, "/"), "/", 5)
+	if len(parts) < 5 || parts[2] != "blob" || parts[4] == "" {
+		return "", "", "", false
+	}
+	return parts[0] + "/" + parts[1], parts[3], parts[4], true
+}
+
+// RefFromURL extracts the branch, tag or commit from a github.com blob URL.
+// HEAD, which stands for the default branch, comes back empty.
+func RefFromURL(blobURL string) string {
+	_, rest, ok := strings.Cut(blobURL, "/blob/")
+	if !ok {
+		return ""
+	}
+	ref, _, _ := strings.Cut(rest, "/")
+	if ref == "HEAD" {
+		return ""
+	}
+	return ref
+}
+
+// PinURL points a github.com blob URL at a commit instead of whatever branch or
+// tag it names, so the file cannot change under a download
+func PinURL(blobURL, commit string) string {
+	prefix, rest, ok := strings.Cut(blobURL, "/blob/")
+	if !ok {
+		return blobURL
+	}
+	_, p, _ := strings.Cut(rest, "/")
+	return prefix + "/blob/" + commit + "/" + p
+}
@@ -0,0 +1,128 @@
+package github
+
+import "testing"
+
+const testSHA = "0123456789abcdef0123456789abcdef01234567"
+
+func TestParseBlobURL(t *testing.T) {
+	tests := []struct {
+		url             string
+		repo, ref, path string
+		ok              bool
+	}{
+		{"https://github.com/acme/tools/blob/main/.claude/agents/reviewer.md", "acme/tools", "main", ".claude/agents/reviewer.md", true},
+		{"https://github.com/acme/tools/blob/" + testSHA + "/CLAUDE.md", "acme/tools", testSHA, "CLAUDE.md", true},
+		{"https://github.com/acme/tools/blob/HEAD/a%20b.md", "acme/tools", "HEAD", "a b.md", true},
+		{"https://ghe.example.com/acme/tools/blob/v1.2/docs/CLAUDE.md", "acme/tools", "v1.2", "docs/CLAUDE.md", true},
+		// A blob URL cannot say where a branch with slashes ends, so the first
+		// segment is taken; selections are pinned to a commit before download
+		{"https://github.com/acme/tools/blob/feature/x/CLAUDE.md", "acme/tools", "feature", "x/CLAUDE.md", true},
+		{"https://raw.githubusercontent.com/acme/tools/main/CLAUDE.md", "", "", "", false},
+		{"https://github.com/acme/tools/tree/main/.claude", "", "", "", false},
+		{"https://github.com/acme/tools/blob/main/", "", "", "", false},
+		{"https://github.com/acme/tools", "", "", "", false},
+		{"://not a url", "", "", "", false},
+		{"", "", "", "", false},
+	}
+
+	for _, tt := range tests {
+		repo, ref, path, ok := ParseBlobURL(tt.url)
+		if repo != tt.repo || ref != tt.ref || path != tt.path || ok != tt.ok {
+			t.Errorf("ParseBlobURL(%q) = %q, %q, %q, %v; want %q, %q, %q, %v", tt.url, repo, ref, path, ok, tt.repo, tt.ref, tt.path, tt.ok)
+		}
+	}
+}
+
+func TestRefFromURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://github.com/acme/tools/blob/main/CLAUDE.md", "main"},
+		{"https://github.com/acme/tools/blob/v1.2/CLAUDE.md", "v1.2"},
+		{"https://github.com/acme/tools/blob/" + testSHA + "/CLAUDE.md", testSHA},
+		{"https://github.com/acme/tools/blob/HEAD/CLAUDE.md", ""},
+		{"https://github.com/acme/tools/blob/feature/x/CLAUDE.md", "feature"},
+		{"https://raw.githubusercontent.com/acme/tools/main/CLAUDE.md", ""},
+		{"not a url", ""},
+	}
+
+	for _, tt := range tests {
+		if got := RefFromURL(tt.url); got != tt.want {
+			t.Errorf("RefFromURL(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestPinURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{
+			"https://github.com/acme/tools/blob/main/.claude/agents/reviewer.md",
+			"https://github.com/acme/tools/blob/" + testSHA + "/.claude/agents/reviewer.md",
+		},
+		{
+			"https://github.com/acme/tools/blob/HEAD/CLAUDE.md",
+			"https://github.com/acme/tools/blob/" + testSHA + "/CLAUDE.md",
+		},
+		{
+			// Already pinned to the same commit: unchanged
+			"https://github.com/acme/tools/blob/" + testSHA + "/CLAUDE.md",
+			"https://github.com/acme/tools/blob/" + testSHA + "/CLAUDE.md",
+		},
+		{
+			"https://ghe.example.com/acme/tools/blob/v1/CLAUDE.md",
+			"https://ghe.example.com/acme/tools/blob/" + testSHA + "/CLAUDE.md",
+		},
+		{
+			// Not a blob URL: left alone
+			"https://raw.githubusercontent.com/acme/tools/main/CLAUDE.md",
+			"https://raw.githubusercontent.com/acme/tools/main/CLAUDE.md",
+		},
+	}
+
+	for _, tt := range tests {
+		if got := PinURL(tt.url, testSHA); got != tt.want {
+			t.Errorf("PinURL(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestBlobURLRoundTrip(t *testing.T) {
+	tests := []struct {
+		baseURL string
+		want    string
+	}{
+		{defaultBaseURL, "https://github.com/acme/tools/blob/" + testSHA + "/docs/CLAUDE.md"},
+		{"https://ghe.example.com/api/v3", "https://ghe.example.com/acme/tools/blob/" + testSHA + "/docs/CLAUDE.md"},
+	}
+
+	for _, tt := range tests {
+		c := &Client{BaseURL: tt.baseURL}
+		got := c.BlobURL("acme/tools", testSHA, "docs/CLAUDE.md")
+		if got != tt.want {
+			t.Errorf("BlobURL with %s = %q, want %q", tt.baseURL, got, tt.want)
+		}
+		repo, ref, path, ok := ParseBlobURL(got)
+		if !ok || repo != "acme/tools" || ref != testSHA || path != "docs/CLAUDE.md" {
+			t.Errorf("ParseBlobURL(%q) = %q, %q, %q, %v", got, repo, ref, path, ok)
+		}
+	}
+}
+
+func TestIsCommitSHA(t *testing.T) {
+	tests := map[string]bool{
+		testSHA:     true,
+		testSHA[:7]: false,
+		"main":      false,
+		"0123456789ABCDEF0123456789ABCDEF01234567": false,
+		"": false,
+	}
+	for ref, want := range tests {
+		if got := IsCommitSHA(ref); got != want {
+			t.Errorf("IsCommitSHA(%q) = %v, want %v", ref, got, want)
+		}
+	}
+}
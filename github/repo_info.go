@@ -0,0 +1,184 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+)
+
+// repoInfoBatchSize is how many aliased repository fields go into one GraphQL query
+const repoInfoBatchSize = 50
+
+// RepoInfo holds the repository metadata used to annotate and rank results
+type RepoInfo struct {
+	Stars    int
+	Fork     bool
+	Archived bool
+	PushedAt time.Time
+	License  string // SPDX identifier, empty when unknown
+}
+
+// buildRepoInfoQuery builds a GraphQL query with one aliased repository field per repo
+func buildRepoInfoQuery(repos []string) string {
+	var b strings.Builder
+	b.WriteString("query {\n")
+	for i, repo := range repos {
+		owner, name, ok := strings.Cut(repo, "/")
+		if !ok {
+			continue
+		}
+		ownerJSON, _ := json.Marshal(owner)
+		nameJSON, _ := json.Marshal(name)
+		fmt.Fprintf(&b, "  r%d: repository(owner: %s, name: %s) { stargazerCount isFork isArchived pushedAt licenseInfo { spdxId } }\n",
+			i, ownerJSON, nameJSON)
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+// parseRepoInfoResponse maps the aliased fields of a GraphQL response back to repo names.
+// Repositories that could not be resolved come back as null and are skipped.
+func parseRepoInfoResponse(data []byte, repos []string, info map[string]RepoInfo) error {
+	var resp struct {
+		Data map[string]*struct {
+			StargazerCount int       `json:"stargazerCount"`
+			IsFork         bool      `json:"isFork"`
+			IsArchived     bool      `json:"isArchived"`
+			PushedAt       time.Time `json:"pushedAt"`
+			LicenseInfo    *struct {
+				SpdxID string `json:"spdxId"`
+			} `json:"licenseInfo"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return fmt.Errorf("failed to decode GraphQL response: %w", err)
+	}
+
+	for i, repo := range repos {
+		r := resp.Data[fmt.Sprintf("r%d", i)]
+		if r == nil {
+			continue
+		}
+		ri := RepoInfo{
+			Stars:    r.StargazerCount,
+			Fork:     r.IsFork,
+			Archived: r.IsArchived,
+			PushedAt: r.PushedAt,
+		}
+		if r.LicenseInfo != nil {
+			ri.License = r.LicenseInfo.SpdxID
+		}
+		info[repo] = ri
+	}
+	return nil
+}
+
+// fetchRepoInfoBatched splits repos into batches and runs each through query
+func fetchRepoInfoBatched(ctx context.Context, repos []string, query func(ctx context.Context, q string) ([]byte, error)) map[string]RepoInfo {
+	info := make(map[string]RepoInfo)
+	for start := 0; start < len(repos) && ctx.Err() == nil; start += repoInfoBatchSize {
+		end := start + repoInfoBatchSize
+		if end > len(repos) {
+			end = len(repos)
+		}
+		batch := repos[start:end]
+
+		data, err := query(ctx, buildRepoInfoQuery(batch))
+		if err != nil {
+			continue
+		}
+		parseRepoInfoResponse(data, batch, info)
+	}
+	return info
+}
+
+// fetchRepoInfoGH fetches repository metadata through `gh api graphql`
+func fetchRepoInfoGH(ctx context.Context, repos []string) map[string]RepoInfo {
+	return fetchRepoInfoBatched(ctx, repos, func(ctx context.Context, q string) ([]byte, error) {
+		return exec.CommandContext(ctx, "gh", "api", "graphql", "-f", "query="+q).Output()
+	})
+}
+
+// FetchRepoInfo fetches repository metadata in batched GraphQL queries,
+// falling back to per-repository REST calls when no token is configured
+func (c *Client) FetchRepoInfo(ctx context.Context, repos []string) map[string]RepoInfo {
+	if c.Token == "" {
+		return c.fetchRepoInfoREST(ctx, repos)
+	}
+	return fetchRepoInfoBatched(ctx, repos, c.graphQL)
+}
+
+// graphQL posts a query to the GraphQL endpoint and returns the raw response body
+func (c *Client) graphQL(ctx context.Context, query string) ([]byte, error) {
+	body, err := json.Marshal(map[string]string{"query": query})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.graphQLURL(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("POST graphql: %w", err)
+	}
+	defer resp.Body.Close()
+	rates.observe(resp.Header)
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, httpError("graphql", resp)
+	}
+
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(resp.Body); err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// graphQLURL derives the GraphQL endpoint from the REST base URL.
+// GitHub Enterprise serves REST under /api/v3 and GraphQL under /api/graphql.
+func (c *Client) graphQLURL() string {
+	if strings.HasSuffix(c.BaseURL, "/api/v3") {
+		return strings.TrimSuffix(c.BaseURL, "/v3") + "/graphql"
+	}
+	return c.BaseURL + "/graphql"
+}
+
+// ApplyRepoInfo copies repository metadata onto results and ranks them
+func ApplyRepoInfo(results []Result, info map[string]RepoInfo) {
+	for i := range results {
+		ri, ok := info[results[i].Repo]
+		if !ok {
+			continue
+		}
+		results[i].Stars = ri.Stars
+		results[i].Fork = ri.Fork
+		results[i].Archived = ri.Archived
+		results[i].PushedAt = ri.PushedAt
+		results[i].License = ri.License
+	}
+	rankResults(results)
+}
+
+// rankResults orders results by stars, sinking archived repositories to the bottom
+func rankResults(results []Result) {
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Archived != results[j].Archived {
+			return !results[i].Archived
+		}
+		return results[i].Stars > results[j].Stars
+	})
+}
@@ -0,0 +1,152 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// aliasPattern picks the aliased repository fields out of a batched query
+var aliasPattern = regexp.MustCompile(`(r\d+): repository\(owner: "([^"]*)", name: "([^"]*)"\)`)
+
+// graphQLStub answers batched repository queries the way GitHub does: one data
+// field per alias, null plus an errors entry for repositories that do not
+// resolve, and a failed request for any batch containing failRepo
+func graphQLStub(t *testing.T, failRepo string, mu *sync.Mutex, batches *[]int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/graphql" || r.Method != "POST" {
+			t.Errorf("%s %s, want POST /graphql", r.Method, r.URL.Path)
+		}
+		var body struct {
+			Query string `json:"query"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("bad request body: %v", err)
+		}
+
+		aliases := aliasPattern.FindAllStringSubmatch(body.Query, -1)
+		mu.Lock()
+		*batches = append(*batches, len(aliases))
+		mu.Unlock()
+
+		data := make(map[string]interface{})
+		var errs []map[string]interface{}
+		for _, a := range aliases {
+			alias, repo := a[1], a[2]+"/"+a[3]
+			if repo == failRepo {
+				http.Error(w, "bad gateway", http.StatusBadGateway)
+				return
+			}
+			if strings.HasPrefix(a[3], "missing") {
+				data[alias] = nil
+				errs = append(errs, map[string]interface{}{
+					"type":    "NOT_FOUND",
+					"path":    []string{alias},
+					"message": fmt.Sprintf("Could not resolve to a Repository with the name '%s'.", repo),
+				})
+				continue
+			}
+			var stars int
+			fmt.Sscanf(a[3], "repo%d", &stars)
+			data[alias] = map[string]interface{}{
+				"stargazerCount": stars,
+				"isFork":         false,
+				"isArchived":     a[3] == "repo7",
+				"pushedAt":       "2026-01-02T03:04:05Z",
+				"licenseInfo":    map[string]string{"spdxId": "MIT"},
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": data, "errors": errs})
+	}
+}
+
+func TestFetchRepoInfoBatched(t *testing.T) {
+	repoList := func(n int, extra ...string) []string {
+		var repos []string
+		for i := 0; i < n; i++ {
+			repos = append(repos, fmt.Sprintf("acme/repo%d", i))
+		}
+		return append(repos, extra...)
+	}
+
+	tests := []struct {
+		name     string
+		repos    []string
+		failRepo string
+		batches  []int  // Aliases per request
+		found    int    // Repositories with metadata
+		missing  string // A repository that must be absent
+	}{
+		{name: "one batch", repos: repoList(3), batches: []int{3}, found: 3},
+		{name: "exactly one full batch", repos: repoList(50), batches: []int{50}, found: 50},
+		{name: "more than fifty repositories", repos: repoList(120), batches: []int{50, 50, 20}, found: 120},
+		{
+			name:    "partial errors keep the rest of the batch",
+			repos:   repoList(60, "acme/missing-one", "ghost/missing-two"),
+			batches: []int{50, 12},
+			found:   60,
+			missing: "acme/missing-one",
+		},
+		{
+			name:     "a failed batch does not stop the others",
+			repos:    repoList(75),
+			failRepo: "acme/repo10",
+			batches:  []int{50, 25},
+			found:    25,
+			missing:  "acme/repo10",
+		},
+		{name: "nothing to look up", batches: nil, found: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var batches []int
+			c, _ := testServer(t, "secret", graphQLStub(t, tt.failRepo, &mu, &batches))
+
+			info := c.FetchRepoInfo(context.Background(), tt.repos)
+			if fmt.Sprint(batches) != fmt.Sprint(tt.batches) {
+				t.Errorf("batches = %v, want %v", batches, tt.batches)
+			}
+			if len(info) != tt.found {
+				t.Errorf("found %d repositories, want %d", len(info), tt.found)
+			}
+			if _, ok := info[tt.missing]; tt.missing != "" && ok {
+				t.Errorf("%s has metadata, want none", tt.missing)
+			}
+			if ri, ok := info["acme/repo7"]; ok && (ri.Stars != 7 || !ri.Archived || ri.License != "MIT" || ri.PushedAt.IsZero()) {
+				t.Errorf("acme/repo7 = %+v, want 7 stars, archived, MIT", ri)
+			}
+		})
+	}
+}
+
+func TestBuildRepoInfoQueryEscapes(t *testing.T) {
+	q := buildRepoInfoQuery([]string{`acme/we"ird`, "not-a-repo", "other/x"})
+	if !strings.Contains(q, `r0: repository(owner: "acme", name: "we\"ird")`) {
+		t.Errorf("quote not escaped in:\n%s", q)
+	}
+	// Aliases follow the input index, so responses map back even when one is skipped
+	if strings.Contains(q, "r1:") || !strings.Contains(q, `r2: repository(owner: "other", name: "x")`) {
+		t.Errorf("unexpected aliases in:\n%s", q)
+	}
+}
+
+func TestGraphQLURL(t *testing.T) {
+	tests := map[string]string{
+		"https://api.github.com":         "https://api.github.com/graphql",
+		"https://ghe.example.com/api/v3": "https://ghe.example.com/api/graphql",
+		"http://127.0.0.1:8080":          "http://127.0.0.1:8080/graphql",
+	}
+	for base, want := range tests {
+		c := &Client{BaseURL: base}
+		if got := c.graphQLURL(); got != want {
+			t.Errorf("graphQLURL for %s = %s, want %s", base, got, want)
+		}
+	}
+}
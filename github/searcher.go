@@ -0,0 +1,215 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+)
+
+// Searcher is a search backend that turns a keyword query into Results
+type Searcher interface {
+	// Search passes batches of results to emit as they are found.
+	// Results carry no repository metadata; that is fetched afterwards via RepoInfo.
+	// The error reports why the search stopped early, if it did.
+	Search(ctx context.Context, query string, opts SearchOptions, emit func([]Result)) error
+
+	// RepoInfo looks up stars and other metadata for the given repositories
+	RepoInfo(ctx context.Context, repos []string) map[string]RepoInfo
+}
+
+// defaultSearcher is the backend used by the package-level Search function
+var defaultSearcher Searcher = NewHTTPSearcher()
+
+// SetSearcher replaces the backend used by Search
+func SetSearcher(s Searcher) {
+	if s != nil {
+		defaultSearcher = s
+	}
+}
+
+// NewSearcher returns the backend registered under name ("gh", "http" or "fake").
+// An empty name prefers the native HTTP client when a token is configured,
+// then gh when it is installed, and the anonymous HTTP client as a last resort.
+func NewSearcher(name string) (Searcher, error) {
+	switch name {
+	case "":
+		if DefaultClient.Token == "" {
+			if _, err := exec.LookPath("gh"); err == nil {
+				return GHSearcher{}, nil
+			}
+		}
+		return NewHTTPSearcher(), nil
+	case "gh":
+		return GHSearcher{}, nil
+	case "http":
+		return NewHTTPSearcher(), nil
+	case "fake":
+		return LoadFakeSearcher(os.Getenv("AGENTDL_FIXTURES"))
+	}
+	return nil, fmt.Errorf("unknown search backend %q", name)
+}
+
+// ============================
+// gh CLI backend
+// ============================
+
+// GHSearcher searches by shelling out to the GitHub CLI
+type GHSearcher struct{}
+
+// Search performs filename search through gh, falling back to a plain path search
+func (GHSearcher) Search(ctx context.Context, query string, opts SearchOptions, emit func([]Result)) error {
+	// Use paginated search with rate limiting for filename-only search
+	if query != "" {
+		return PaginatedSearchByFilename(ctx, query, opts, emit)
+	}
+	// Fall back to original approach for empty queries
+	return searchFallback(ctx, query, opts, emit)
+}
+
+// RepoInfo fetches repository metadata through `gh api graphql`
+func (GHSearcher) RepoInfo(ctx context.Context, repos []string) map[string]RepoInfo {
+	return fetchRepoInfoGH(ctx, repos)
+}
+
+// ============================
+// Native HTTP backend
+// ============================
+
+// HTTPSearcher talks to the GitHub REST API directly without the gh CLI
+type HTTPSearcher struct {
+	Client *Client
+}
+
+// NewHTTPSearcher creates an HTTP backend using DefaultClient
+func NewHTTPSearcher() *HTTPSearcher {
+	return &HTTPSearcher{Client: DefaultClient}
+}
+
+// Search runs a content search restricted to the mode's path, emitting each page
+// once it has been filtered by filename
+func (s *HTTPSearcher) Search(ctx context.Context, query string, opts SearchOptions, emit func([]Result)) error {
+	if opts.Limit <= 0 {
+		opts.Limit = 100
+	}
+
+	found := 0
+	err := s.Client.SearchCodePages(ctx, buildContentSearchQuery(query, opts), func(page []Result) bool {
+		var results []Result
+		for _, r := range page {
+			// Only include files this mode can install
+			if wantedFile(r.Path, opts) {
+				results = append(results, r)
+			}
+		}
+
+		results = filterByFilename(results, query, opts)
+		if found+len(results) > opts.Limit {
+			results = results[:opts.Limit-found]
+		}
+		found += len(results)
+		if len(results) > 0 {
+			emit(results)
+		}
+		return found < opts.Limit
+	})
+	if err != nil && ctx.Err() == nil {
+		log.Printf("GitHub search failed: %v", err)
+	}
+	return err
+}
+
+// RepoInfo fetches repository metadata through the REST client
+func (s *HTTPSearcher) RepoInfo(ctx context.Context, repos []string) map[string]RepoInfo {
+	return s.Client.FetchRepoInfo(ctx, repos)
+}
+
+// ============================
+// In-memory fake backend
+// ============================
+
+// FakeSearcher serves fixed fixtures, for exercising the TUI without network access
+type FakeSearcher struct {
+	Results []Result
+}
+
+// LoadFakeSearcher reads fixtures from a JSON file containing an array of Results
+func LoadFakeSearcher(path string) (*FakeSearcher, error) {
+	if path == "" {
+		return &FakeSearcher{}, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixtures: %w", err)
+	}
+
+	var results []Result
+	if err := json.Unmarshal(data, &results); err != nil {
+		return nil, fmt.Errorf("failed to parse fixtures: %w", err)
+	}
+	for i := range results {
+		if results[i].RelPath == "" {
+			results[i].RelPath = relPathFor(results[i].Repo, results[i].Path)
+		}
+	}
+
+	return &FakeSearcher{Results: results}, nil
+}
+
+// Search emits the fixtures under the mode's path whose filenames match the query
+func (f *FakeSearcher) Search(ctx context.Context, query string, opts SearchOptions, emit func([]Result)) error {
+	var matched []Result
+	for _, r := range f.Results {
+		if wantedFile(r.Path, opts) {
+			matched = append(matched, r)
+		}
+	}
+
+	matched = filterByFilename(matched, query, opts)
+	if opts.Limit > 0 && len(matched) > opts.Limit {
+		matched = matched[:opts.Limit]
+	}
+
+	if len(matched) > 0 {
+		emit(matched)
+	}
+	return nil
+}
+
+// RepoInfo reports the star counts recorded in the fixtures
+func (f *FakeSearcher) RepoInfo(ctx context.Context, repos []string) map[string]RepoInfo {
+	wanted := make(map[string]bool)
+	for _, repo := range repos {
+		wanted[repo] = true
+	}
+
+	info := make(map[string]RepoInfo)
+	for _, r := range f.Results {
+		// Fixtures may repeat a repo; keep the entry with the most stars
+		if existing, ok := info[r.Repo]; !wanted[r.Repo] || (ok && existing.Stars >= r.Stars) {
+			continue
+		}
+		info[r.Repo] = RepoInfo{
+			Stars:    r.Stars,
+			Fork:     r.Fork,
+			Archived: r.Archived,
+			PushedAt: r.PushedAt,
+			License:  r.License,
+		}
+	}
+	return info
+}
+
+// relPathFor builds the display path, trimming everything up to the kind's directory.
+// Skills are shown as their directory, since the whole folder is what gets installed.
+func relPathFor(repo, path string) string {
+	if kind, ok := kindOf(path); ok {
+		if rel, ok := kind.relPath(path); ok {
+			return repo + "/" + rel
+		}
+	}
+	return repo + "/" + path
+}
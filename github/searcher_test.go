@@ -0,0 +1,107 @@
+package github
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFakeSearcher(t *testing.T) {
+	fake := &FakeSearcher{Results: []Result{
+		{Repo: "acme/tools", Path: ".claude/agents/code-reviewer.md", Stars: 10},
+		{Repo: "acme/tools", Path: ".claude/agents/test-writer.md", Stars: 10},
+		{Repo: "other/x", Path: ".claude/agents/reviewer.md", Stars: 3},
+		{Repo: "other/x", Path: ".claude/commands/review.md", Stars: 3},
+		{Repo: "other/x", Path: "docs/reviewer.md", Stars: 3},
+	}}
+
+	tests := []struct {
+		name  string
+		query string
+		opts  SearchOptions
+		want  []string // Paths, in order
+	}{
+		{
+			name: "every file of the mode",
+			opts: SearchOptions{SearchMode: ModeAgents},
+			want: []string{".claude/agents/code-reviewer.md", ".claude/agents/test-writer.md", ".claude/agents/reviewer.md"},
+		},
+		{
+			name:  "all keywords in the name",
+			query: "code reviewer",
+			opts:  SearchOptions{SearchMode: ModeAgents, MatchMode: "all"},
+			want:  []string{".claude/agents/code-reviewer.md"},
+		},
+		{
+			name:  "any keyword in the name",
+			query: "code test",
+			opts:  SearchOptions{SearchMode: ModeAgents, MatchMode: "any"},
+			want:  []string{".claude/agents/code-reviewer.md", ".claude/agents/test-writer.md"},
+		},
+		{
+			name:  "other modes",
+			query: "review",
+			opts:  SearchOptions{SearchMode: ModeCommands},
+			want:  []string{".claude/commands/review.md"},
+		},
+		{
+			name: "limit",
+			opts: SearchOptions{SearchMode: ModeAgents, Limit: 1},
+			want: []string{".claude/agents/code-reviewer.md"},
+		},
+		{
+			name:  "nothing matches",
+			query: "deploy",
+			opts:  SearchOptions{SearchMode: ModeAgents},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			batches := 0
+			err := fake.Search(context.Background(), tt.query, tt.opts, func(results []Result) {
+				batches++
+				for _, r := range results {
+					got = append(got, r.Path)
+				}
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("paths = %q, want %q", got, tt.want)
+			}
+			if len(tt.want) == 0 && batches != 0 {
+				t.Errorf("emitted %d empty batches", batches)
+			}
+		})
+	}
+
+	info := fake.RepoInfo(context.Background(), []string{"other/x", "missing/repo"})
+	if want := map[string]RepoInfo{"other/x": {Stars: 3}}; !reflect.DeepEqual(info, want) {
+		t.Errorf("RepoInfo = %+v, want %+v", info, want)
+	}
+}
+
+func TestLoadFakeSearcher(t *testing.T) {
+	fixtures := filepath.Join(t.TempDir(), "fixtures.json")
+	data := `[{"repo": "acme/tools", "path": ".claude/agents/reviewer.md", "stars": 4}]`
+	if err := os.WriteFile(fixtures, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	fake, err := LoadFakeSearcher(fixtures)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.Results) != 1 || fake.Results[0].RelPath != "acme/tools/reviewer.md" {
+		t.Errorf("results = %+v, want one with its display path filled in", fake.Results)
+	}
+
+	if _, err := LoadFakeSearcher(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("loaded missing fixtures, want an error")
+	}
+}
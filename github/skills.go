@@ -0,0 +1,61 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// skillFile is the entry point every skill directory must contain
+const skillFile = "SKILL.md"
+
+// SkillDir returns the skill directory containing a SKILL.md path, or "" when the
+// path is not a skill's entry point
+func SkillDir(p string) string {
+	if kind, ok := kindOf(p); !ok || !kind.NamedByDir {
+		return ""
+	}
+	return path.Dir(p)
+}
+
+// IsSkillDir reports whether dir is a skill folder directly under the directory
+// skills are kept in
+func IsSkillDir(dir string) bool {
+	for _, kind := range Kinds {
+		if kind.NamedByDir && strings.HasSuffix("/"+path.Dir(dir), "/"+kind.DefaultPath()) {
+			return true
+		}
+	}
+	return false
+}
+
+// ListTree lists every file beneath dir at ref, descending into subdirectories.
+// An empty ref means the repository's default branch.
+func (c *Client) ListTree(ctx context.Context, repo, dir, ref string) ([]ContentItem, error) {
+	listing := fmt.Sprintf("/repos/%s/contents/%s", repo, dir)
+	if ref != "" {
+		listing += "?ref=" + url.QueryEscape(ref)
+	}
+
+	var page []ContentItem
+	if err := c.getJSON(ctx, listing, &page); err != nil {
+		return nil, err
+	}
+
+	var files []ContentItem
+	for _, item := range page {
+		switch item.Type {
+		case "file":
+			files = append(files, item)
+		case "dir":
+			sub, err := c.ListTree(ctx, repo, item.Path, ref)
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, sub...)
+		}
+	}
+	return files, nil
+}
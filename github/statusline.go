@@ -0,0 +1,36 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// StatusLine is the statusLine block of a settings.json
+type StatusLine struct {
+	Type    string `json:"type"`
+	Command string `json:"command"`
+}
+
+// SetStatusLine points a settings.json at a statusline command, keeping every
+// other setting. Empty settings start a new file.
+func SetStatusLine(settings []byte, command string) ([]byte, error) {
+	top := make(map[string]json.RawMessage)
+	if len(strings.TrimSpace(string(settings))) > 0 {
+		if err := json.Unmarshal(settings, &top); err != nil {
+			return nil, fmt.Errorf("failed to parse settings: %w", err)
+		}
+	}
+
+	raw, err := json.Marshal(StatusLine{Type: "command", Command: command})
+	if err != nil {
+		return nil, err
+	}
+	top["statusLine"] = raw
+
+	out, err := json.MarshalIndent(top, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
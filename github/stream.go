@@ -0,0 +1,221 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// Update is one increment of a streaming search
+type Update struct {
+	Results  []Result            // Newly found results, without repository metadata
+	RepoInfo map[string]RepoInfo // Metadata for every repository seen, sent once after all results
+	Metadata map[string]Metadata // Frontmatter for earlier results, keyed by ResultKey
+	Err      error               // Why the search stopped early; set only on the last Update
+}
+
+// Stream searches with the configured backend and pushes results over the returned
+// channel in batches as they are found. Once the backend finishes, a final Update
+// carrying repository metadata and any search error is sent and the channel is
+// closed. For kinds with frontmatter, each batch is followed by an Update carrying
+// its parsed metadata once fetched. Cancelling ctx stops the search and closes the
+// channel early.
+func Stream(ctx context.Context, query string, opts SearchOptions) <-chan Update {
+	ch := make(chan Update)
+	searcher := defaultSearcher
+
+	// Fixtures change between test runs, so the fake backend bypasses the cache
+	_, fake := searcher.(*FakeSearcher)
+	cacheKey := searchCacheKey(searcher, query, opts)
+
+	go func() {
+		defer close(ch)
+
+		var cached []Result
+		if !fake && DefaultCache.getJSON(cacheKey, searchCacheTTL, &cached) {
+			select {
+			case ch <- Update{Results: cached, RepoInfo: repoInfoFromResults(cached)}:
+			case <-ctx.Done():
+			}
+			return
+		}
+
+		var all []Result
+		kind := KindFor(opts.SearchMode)
+		meta := make(map[string]Metadata)
+		var metaMu sync.Mutex
+		var metaWG sync.WaitGroup
+		seen := make(map[string]bool)
+		seenRepos := make(map[string]bool)
+		var repos []string
+		send := func(u Update) {
+			// Checked first so a backend that emits after cancellation is never
+			// raced against a waiting reader
+			if ctx.Err() != nil {
+				return
+			}
+			select {
+			case ch <- u:
+			case <-ctx.Done():
+			}
+		}
+
+		err := searcher.Search(ctx, query, opts, func(batch []Result) {
+			// Drop results already delivered in an earlier batch
+			var fresh []Result
+			if kind.Install == InstallMCP && !fake {
+				batch = expandMCPServers(ctx, batch, query, opts)
+			}
+			for _, r := range batch {
+				key := ResultKey(r)
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+				fresh = append(fresh, r)
+				if !seenRepos[r.Repo] {
+					seenRepos[r.Repo] = true
+					repos = append(repos, r.Repo)
+				}
+			}
+			// Hook settings are only worth showing when they configure hooks
+			if kind.Install == InstallHooks && !fake {
+				fresh = annotateHooks(ctx, fresh)
+			}
+			if len(fresh) == 0 {
+				return
+			}
+			all = append(all, fresh...)
+			send(Update{Results: fresh})
+
+			// Frontmatter follows in the background so results show up at once
+			if kind.Frontmatter && !fake {
+				metaWG.Add(1)
+				go func(batch []Result) {
+					defer metaWG.Done()
+					m := fetchMetadata(ctx, batch)
+					if len(m) == 0 {
+						return
+					}
+					metaMu.Lock()
+					for k, v := range m {
+						meta[k] = v
+					}
+					metaMu.Unlock()
+					send(Update{Metadata: m})
+				}(fresh)
+			}
+		})
+		metaWG.Wait()
+
+		if ctx.Err() != nil {
+			return
+		}
+		ApplyMetadata(all, meta)
+
+		final := Update{Err: err}
+		if len(repos) > 0 {
+			final.RepoInfo = cachedRepoInfo(ctx, searcher, repos, !fake)
+			ApplyRepoInfo(all, final.RepoInfo)
+		}
+		// Only complete searches are worth replaying
+		if err == nil && !fake && ctx.Err() == nil && len(all) > 0 {
+			DefaultCache.putJSON(cacheKey, all)
+		}
+		if final.Err != nil || final.RepoInfo != nil {
+			send(final)
+		}
+	}()
+
+	return ch
+}
+
+// Search performs intelligent keyword search on GitHub using the configured backend,
+// waiting for every batch and ranking the annotated results.
+// Cancelling ctx aborts in-flight subprocesses, HTTP requests and rate limit waits.
+// Results found before a failure are returned alongside the error.
+func Search(ctx context.Context, query string, opts SearchOptions) ([]Result, error) {
+	var results []Result
+	var err error
+	for u := range Stream(ctx, query, opts) {
+		results = append(results, u.Results...)
+		if u.Metadata != nil {
+			ApplyMetadata(results, u.Metadata)
+		}
+		if u.RepoInfo != nil {
+			ApplyRepoInfo(results, u.RepoInfo)
+		}
+		if u.Err != nil {
+			err = u.Err
+		}
+	}
+	if err == nil {
+		err = ctx.Err()
+	}
+	return results, err
+}
+
+// searchCacheKey identifies a search by everything that affects its results,
+// including which backend ran it against which GitHub
+func searchCacheKey(searcher Searcher, query string, opts SearchOptions) string {
+	return fmt.Sprintf("search %s %d %s %d %s", backendKey(searcher), opts.SearchMode, opts.MatchMode, opts.Limit, query)
+}
+
+// backendKey names a backend and the server it talks to. gh picks its host from
+// GH_HOST; the HTTP client from its base URL.
+func backendKey(searcher Searcher) string {
+	switch s := searcher.(type) {
+	case GHSearcher:
+		host := os.Getenv("GH_HOST")
+		if host == "" {
+			host = "github.com"
+		}
+		return "gh " + host
+	case *HTTPSearcher:
+		return "http " + s.Client.BaseURL
+	}
+	return fmt.Sprintf("%T", searcher)
+}
+
+// cachedRepoInfo serves repository metadata from the cache, asking the backend only
+// for repositories that are missing or expired
+func cachedRepoInfo(ctx context.Context, searcher Searcher, repos []string, useCache bool) map[string]RepoInfo {
+	if !useCache {
+		return searcher.RepoInfo(ctx, repos)
+	}
+
+	info := make(map[string]RepoInfo)
+	var missing []string
+	for _, repo := range repos {
+		var ri RepoInfo
+		if DefaultCache.getJSON("repo "+repo, repoInfoCacheTTL, &ri) {
+			info[repo] = ri
+		} else {
+			missing = append(missing, repo)
+		}
+	}
+
+	if len(missing) > 0 {
+		for repo, ri := range searcher.RepoInfo(ctx, missing) {
+			info[repo] = ri
+			DefaultCache.putJSON("repo "+repo, ri)
+		}
+	}
+	return info
+}
+
+// repoInfoFromResults rebuilds repository metadata from annotated results
+func repoInfoFromResults(results []Result) map[string]RepoInfo {
+	info := make(map[string]RepoInfo)
+	for _, r := range results {
+		info[r.Repo] = RepoInfo{
+			Stars:    r.Stars,
+			Fork:     r.Fork,
+			Archived: r.Archived,
+			PushedAt: r.PushedAt,
+			License:  r.License,
+		}
+	}
+	return info
+}
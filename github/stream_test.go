@@ -0,0 +1,103 @@
+package github
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// lateSearcher emits its first fixture, then waits for cancellation and emits
+// the rest anyway, like a backend whose in-flight request finishes after the
+// user gave up on the search
+type lateSearcher struct {
+	FakeSearcher
+	emitted chan struct{}
+}
+
+func (s *lateSearcher) Search(ctx context.Context, query string, opts SearchOptions, emit func([]Result)) error {
+	emit(s.Results[:1])
+	close(s.emitted)
+	<-ctx.Done()
+	emit(s.Results[1:])
+	return ctx.Err()
+}
+
+// useSearcher swaps the package backend and cache for the length of a test
+func useSearcher(t *testing.T, s Searcher) {
+	t.Helper()
+	searcher, cache := defaultSearcher, DefaultCache
+	t.Cleanup(func() { defaultSearcher, DefaultCache = searcher, cache })
+	SetSearcher(s)
+	DefaultCache = testCache(t)
+}
+
+// drain reads ch until it closes, failing if that takes too long
+func drain(t *testing.T, ch <-chan Update) []Update {
+	t.Helper()
+	var updates []Update
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case u, ok := <-ch:
+			if !ok {
+				return updates
+			}
+			updates = append(updates, u)
+		case <-timeout:
+			t.Fatal("stream did not close")
+		}
+	}
+}
+
+var streamFixtures = []Result{
+	{Repo: "acme/tools", Path: ".claude/agents/reviewer.md", Stars: 5},
+	{Repo: "other/x", Path: ".claude/agents/tester.md", Stars: 2},
+}
+
+func TestStream(t *testing.T) {
+	useSearcher(t, &FakeSearcher{Results: streamFixtures})
+
+	updates := drain(t, Stream(context.Background(), "", SearchOptions{SearchMode: ModeAgents}))
+	if len(updates) != 2 {
+		t.Fatalf("got %d updates, want a batch and the final repository info", len(updates))
+	}
+	if len(updates[0].Results) != 2 {
+		t.Errorf("first update has %d results, want 2", len(updates[0].Results))
+	}
+	final := updates[1]
+	if final.Err != nil || final.RepoInfo["acme/tools"].Stars != 5 {
+		t.Errorf("final update = %+v, want repository info and no error", final)
+	}
+}
+
+func TestStreamCancelledBeforeStart(t *testing.T) {
+	useSearcher(t, &FakeSearcher{Results: streamFixtures})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if updates := drain(t, Stream(ctx, "", SearchOptions{SearchMode: ModeAgents})); len(updates) != 0 {
+		t.Errorf("got %d updates from a cancelled search, want none", len(updates))
+	}
+}
+
+func TestStreamNoUpdatesAfterCancel(t *testing.T) {
+	// Repeat, since a missed check would only lose a race some of the time
+	for i := 0; i < 50; i++ {
+		s := &lateSearcher{FakeSearcher: FakeSearcher{Results: streamFixtures}, emitted: make(chan struct{})}
+		useSearcher(t, s)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		ch := Stream(ctx, "", SearchOptions{SearchMode: ModeAgents})
+
+		first, ok := <-ch
+		if !ok || len(first.Results) != 1 {
+			t.Fatalf("first update = %+v, %v; want the first batch", first, ok)
+		}
+		<-s.emitted
+		cancel()
+
+		if updates := drain(t, ch); len(updates) != 0 {
+			t.Fatalf("run %d: got %d updates after cancelling, want none: %+v", i, len(updates), updates)
+		}
+	}
+}
@@ -1,11 +1,12 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"os/exec"
 	"strings"
 
+	"agent-search/github"
+	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -34,6 +35,13 @@ type RepoViewer struct {
 	selections  *SelectionManager // Global selection manager
 	width       int
 	height      int
+	ctx         context.Context    // Scopes API requests to the viewer's lifetime
+	cancel      context.CancelFunc // Aborts in-flight requests when the viewer closes
+	ref         string             // Branch or tag being browsed; empty for the default branch
+	branch      string             // Name of the branch or tag shown in the header
+	commit      string             // Commit ref resolved to; listings and selections are pinned to it
+	refInput    textinput.Model    // Where a branch or tag is typed
+	editingRef  bool
 }
 
 type repoItem struct {
@@ -48,6 +56,13 @@ type repoContentsMsg struct {
 	err   error
 }
 
+// repoRefMsg carries the commit the browsed branch or tag resolved to
+type repoRefMsg struct {
+	branch string
+	commit string
+	err    error
+}
+
 type repoFileMsg struct {
 	content string
 	err     error
@@ -58,6 +73,13 @@ type backToResultsMsg struct{}
 // NewRepoViewer creates a new repository viewer
 func NewRepoViewer(repo string, stars int, selections *SelectionManager) RepoViewer {
 	vp := viewport.New(80, 20)
+	ctx, cancel := context.WithCancel(context.Background())
+	
+	refInput := textinput.New()
+	refInput.Placeholder = "branch or tag (empty for default)"
+	refInput.CharLimit = 100
+	refInput.Width = 40
+	
 	return RepoViewer{
 		repo:       repo,
 		stars:      stars,
@@ -66,11 +88,21 @@ func NewRepoViewer(repo string, stars int, selections *SelectionManager) RepoVie
 		selections: selections,
 		width:      80,
 		height:     24,
+		ctx:        ctx,
+		cancel:     cancel,
+		refInput:   refInput,
+	}
+}
+
+// Close aborts any requests still in flight
+func (r RepoViewer) Close() {
+	if r.cancel != nil {
+		r.cancel()
 	}
 }
 
 func (r RepoViewer) Init() tea.Cmd {
-	return r.loadContents("")
+	return r.resolveRef()
 }
 
 func (r RepoViewer) Update(msg tea.Msg) (RepoViewer, tea.Cmd) {
@@ -81,6 +113,16 @@ func (r RepoViewer) Update(msg tea.Msg) (RepoViewer, tea.Cmd) {
 		r.viewport.Width = msg.Width
 		r.viewport.Height = msg.Height - 5 // Leave room for header/footer
 		
+	case repoRefMsg:
+		if msg.err != nil {
+			r.err = msg.err
+			return r, nil
+		}
+		r.branch = msg.branch
+		r.commit = msg.commit
+		r.path = ""
+		return r, r.loadContents("")
+		
 	case repoContentsMsg:
 		if msg.err != nil {
 			r.err = msg.err
@@ -100,6 +142,25 @@ func (r RepoViewer) Update(msg tea.Msg) (RepoViewer, tea.Cmd) {
 		r.viewingFile = true
 		
 	case tea.KeyMsg:
+		if r.editingRef {
+			switch msg.String() {
+			case "esc":
+				r.editingRef = false
+				r.refInput.Blur()
+				return r, nil
+			case "enter":
+				r.editingRef = false
+				r.refInput.Blur()
+				r.ref = strings.TrimSpace(r.refInput.Value())
+				r.err = nil
+				r.items = nil
+				return r, r.resolveRef()
+			}
+			var cmd tea.Cmd
+			r.refInput, cmd = r.refInput.Update(msg)
+			return r, cmd
+		}
+		
 		if r.viewingFile {
 			// File viewing mode
 			switch msg.String() {
@@ -121,6 +182,13 @@ func (r RepoViewer) Update(msg tea.Msg) (RepoViewer, tea.Cmd) {
 			case "q", "esc":
 				return r, func() tea.Msg { return backToResultsMsg{} }
 				
+			case "@":
+				// Browse another branch or tag
+				r.editingRef = true
+				r.refInput.SetValue(r.ref)
+				r.refInput.CursorEnd()
+				return r, r.refInput.Focus()
+				
 			case "up", "k":
 				if r.cursor > 0 {
 					r.cursor--
@@ -132,19 +200,29 @@ func (r RepoViewer) Update(msg tea.Msg) (RepoViewer, tea.Cmd) {
 				}
 				
 			case " ", "space":
-				// Toggle selection on .md files only
+				// Toggle selection on .md files and skill directories only
 				if r.cursor < len(r.items) {
 					item := r.items[r.cursor]
-					if item.Type == "file" && strings.HasSuffix(item.Name, ".md") {
-						// Build the full GitHub URL for this file
-						url := fmt.Sprintf("https://github.com/%s/blob/main/%s", r.repo, item.Path)
-						
+					if isSkillDir(item) {
+						sel := GlobalSelection{
+							Repo:     r.repo,
+							Path:     item.Path + "/SKILL.md",
+							URL:      blobURL(r.repo, r.commit, item.Path+"/SKILL.md"),
+							FileName: item.Name,
+							Source:   "repo",
+							Dir:      true,
+							Ref:      r.ref,
+						}
+						r.selections.Toggle(sel)
+					} else if item.Type == "file" && strings.HasSuffix(item.Name, ".md") {
+						// Build the full GitHub URL for this file, pinned to the browsed commit
 						sel := GlobalSelection{
 							Repo:     r.repo,
 							Path:     item.Path,
-							URL:      url,
+							URL:      blobURL(r.repo, r.commit, item.Path),
 							FileName: item.Name,
 							Source:   "repo",
+							Ref:      r.ref,
 						}
 						r.selections.Toggle(sel)
 					}
@@ -192,6 +270,9 @@ func (r RepoViewer) View() string {
 		repoName = "..." + repoName[len(repoName)-27:]
 	}
 	header := titleStyle.Render(fmt.Sprintf("📂 %s ⭐ %d", repoName, r.stars))
+	if r.commit != "" {
+		header += dimStyle.Render(fmt.Sprintf(" @ %s (%s)", r.branch, shortSHA(r.commit)))
+	}
 	if r.path != "" {
 		pathDisplay := r.path
 		// Truncate path if too long
@@ -203,7 +284,12 @@ func (r RepoViewer) View() string {
 	
 	// Content
 	var content string
-	if r.viewingFile {
+	if r.editingRef {
+		content = "Browse branch or tag:\n\n" + r.refInput.View()
+		content += "\n" + helpStyle.Render("enter: browse • esc: cancel")
+	} else if r.commit == "" {
+		content = dimStyle.Render("Resolving " + r.repo + "...")
+	} else if r.viewingFile {
 		// Show file content in viewport
 		content = subtitleStyle.Render(fmt.Sprintf("📄 %s", r.fileName)) + "\n"
 		content += r.viewport.View()
@@ -251,9 +337,15 @@ func (r RepoViewer) View() string {
 				name = name[:maxNameLen-3] + "..."
 			}
 			
-			// Add selection checkbox for .md files
+			// Add selection checkbox for .md files and skills
 			checkbox := "  "
-			if item.Type == "file" && strings.HasSuffix(item.Name, ".md") {
+			if isSkillDir(item) {
+				if r.selections.IsSelected(r.repo, item.Path+"/SKILL.md") {
+					checkbox = "[x]"
+				} else {
+					checkbox = "[ ]"
+				}
+			} else if item.Type == "file" && strings.HasSuffix(item.Name, ".md") {
 				if r.selections.IsSelected(r.repo, item.Path) {
 					checkbox = "[x]"
 				} else {
@@ -285,7 +377,7 @@ func (r RepoViewer) View() string {
 		repoSelCount := len(r.selections.GetRepoSelections(r.repo))
 		totalSelCount := r.selections.Count()
 		
-		helpText := "↑/↓: navigate • enter: open • space: select • backspace: up • q: quit"
+		helpText := "↑/↓: navigate • enter: open • space: select • backspace: up • @: branch/tag • q: quit"
 		if totalSelCount > 0 {
 			selInfo := fmt.Sprintf("%d selected", totalSelCount)
 			if repoSelCount > 0 && repoSelCount != totalSelCount {
@@ -299,29 +391,32 @@ func (r RepoViewer) View() string {
 	return lipgloss.JoinVertical(lipgloss.Left, header, "", content)
 }
 
-func (r RepoViewer) loadContents(path string) tea.Cmd {
+// resolveRef looks up the commit the browsed branch or tag points at, naming the
+// default branch when none was given
+func (r RepoViewer) resolveRef() tea.Cmd {
 	return func() tea.Msg {
-		var url string
-		if path == "" {
-			url = fmt.Sprintf("repos/%s/contents", r.repo)
-		} else {
-			url = fmt.Sprintf("repos/%s/contents/%s", r.repo, path)
+		branch := r.ref
+		if branch == "" {
+			name, err := github.DefaultClient.DefaultBranch(r.ctx, r.repo)
+			if err != nil {
+				return repoRefMsg{err: fmt.Errorf("failed to find the default branch: %w", err)}
+			}
+			branch = name
 		}
 		
-		cmd := exec.Command("gh", "api", url, "--paginate")
-		output, err := cmd.Output()
+		commit, err := github.DefaultClient.ResolveCommit(r.ctx, r.repo, branch)
 		if err != nil {
-			return repoContentsMsg{err: fmt.Errorf("failed to load directory")}
-		}
-		
-		var ghItems []struct {
-			Name string `json:"name"`
-			Path string `json:"path"`
-			Type string `json:"type"`
+			return repoRefMsg{err: fmt.Errorf("failed to resolve %s: %w", branch, err)}
 		}
-		
-		if err := json.Unmarshal(output, &ghItems); err != nil {
-			return repoContentsMsg{err: err}
+		return repoRefMsg{branch: branch, commit: commit}
+	}
+}
+
+func (r RepoViewer) loadContents(path string) tea.Cmd {
+	return func() tea.Msg {
+		ghItems, err := github.DefaultClient.Contents(r.ctx, r.repo, path, r.commit)
+		if err != nil {
+			return repoContentsMsg{err: fmt.Errorf("failed to load directory: %w", err)}
 		}
 		
 		items := make([]repoItem, 0, len(ghItems))
@@ -339,15 +434,16 @@ func (r RepoViewer) loadContents(path string) tea.Cmd {
 
 func (r RepoViewer) loadFile(path string) tea.Cmd {
 	return func() tea.Msg {
-		cmd := exec.Command("gh", "api",
-			fmt.Sprintf("repos/%s/contents/%s", r.repo, path),
-			"-H", "Accept: application/vnd.github.v3.raw")
-		
-		output, err := cmd.Output()
+		content, err := github.DefaultClient.FileContent(r.ctx, r.repo, path, r.commit)
 		if err != nil {
-			return repoFileMsg{err: fmt.Errorf("failed to load file")}
+			return repoFileMsg{err: fmt.Errorf("failed to load file: %w", err)}
 		}
 		
-		return repoFileMsg{content: string(output)}
+		return repoFileMsg{content: content}
 	}
-}
\ No newline at end of file
+}
+
+// isSkillDir reports whether an item is a skill folder, installed as a whole
+func isSkillDir(item repoItem) bool {
+	return item.Type == "dir" && github.IsSkillDir(item.Path)
+}
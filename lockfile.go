@@ -0,0 +1,136 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ============================
+// Install Lockfile
+// ============================
+
+// lockFileName is the manifest recording where installed files came from
+const lockFileName = "agentdl.lock"
+
+// lockVersion is bumped whenever the lockfile format changes incompatibly
+const lockVersion = 1
+
+// LockFile records the provenance of everything installed under one .claude
+// directory, so installs can be reproduced, audited and updated
+type LockFile struct {
+	Version int         `json:"version"`
+	Entries []LockEntry `json:"entries"`
+}
+
+// LockEntry is one installed artifact
+type LockEntry struct {
+	Kind        string    `json:"kind"` // Kind slug, as accepted by --mode
+	Repo        string    `json:"repo"`
+	Path        string    `json:"path"`               // Path in the repository; a skill's SKILL.md
+	Server      string    `json:"server,omitempty"`   // MCP server taken from the file
+	Sections    []string  `json:"sections,omitempty"` // CLAUDE.md sections imported, by heading; none means all
+	Ref         string    `json:"ref,omitempty"`      // Branch or tag installed from; empty for the default branch
+	Commit      string    `json:"commit,omitempty"`   // Commit the content was resolved to
+	SHA256      string    `json:"sha256"`             // Hash of the downloaded content
+	Target      string    `json:"target"`             // Installed file or directory, relative to the lockfile when beneath it
+	InstalledAt time.Time `json:"installedAt"`
+}
+
+// key identifies the artifact an entry is for; reinstalling replaces the entry
+func (e LockEntry) key() string {
+	return e.Kind + " " + e.Repo + ":" + e.Path + "#" + e.Server
+}
+
+// lockPath is the lockfile for an install location: the .claude directory it is
+// in or, for locations beside one (the home directory, a project root), the
+// .claude directory it contains. Any other directory keeps its own lockfile.
+func lockPath(location string) string {
+	abs, err := filepath.Abs(location)
+	if err != nil {
+		abs = location
+	}
+
+	for dir := abs; ; dir = filepath.Dir(dir) {
+		if filepath.Base(dir) == ".claude" {
+			return filepath.Join(dir, lockFileName)
+		}
+		if filepath.Dir(dir) == dir {
+			break
+		}
+	}
+
+	home, _ := os.UserHomeDir()
+	cwd, _ := os.Getwd()
+	if abs == home || abs == cwd {
+		return filepath.Join(abs, ".claude", lockFileName)
+	}
+	return filepath.Join(abs, lockFileName)
+}
+
+// readLockFile loads a lockfile, returning an empty one when it does not exist yet
+func readLockFile(path string) (*LockFile, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &LockFile{Version: lockVersion}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var lock LockFile
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if lock.Version > lockVersion {
+		return nil, fmt.Errorf("%s: version %d is newer than this agentdl understands", path, lock.Version)
+	}
+	return &lock, nil
+}
+
+// record adds an entry, replacing any earlier install of the same artifact
+func (l *LockFile) record(entry LockEntry) {
+	for i, e := range l.Entries {
+		if e.key() == entry.key() {
+			l.Entries[i] = entry
+			return
+		}
+	}
+	l.Entries = append(l.Entries, entry)
+}
+
+// stage adds the lockfile to an install, so it is replaced together with the
+// files it records
+func (l *LockFile) stage(s *stagedInstall, path string) error {
+	l.Version = lockVersion
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	return s.write(path, string(data)+"\n", 0644)
+}
+
+// lockTarget records an installed path relative to the lockfile's directory, or
+// absolute when it is elsewhere
+func lockTarget(lockFile, dest string) string {
+	abs, err := filepath.Abs(dest)
+	if err != nil {
+		return dest
+	}
+	rel, err := filepath.Rel(filepath.Dir(lockFile), abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return abs
+	}
+	return filepath.ToSlash(rel)
+}
+
+// contentHash is the hex SHA-256 of downloaded content
+func contentHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
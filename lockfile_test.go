@@ -0,0 +1,120 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"agent-search/github"
+)
+
+func TestLockPath(t *testing.T) {
+	home := t.TempDir()
+	project := t.TempDir()
+	other := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Chdir(project)
+
+	tests := []struct {
+		name     string
+		location string
+		want     string
+	}{
+		{"global agents", installLocation("global", github.ModeAgents), filepath.Join(home, ".claude", lockFileName)},
+		{"global skills", installLocation("global", github.ModeSkills), filepath.Join(home, ".claude", lockFileName)},
+		{"global CLAUDE.md", installLocation("global", github.ModeMemory), filepath.Join(home, ".claude", lockFileName)},
+		{"global MCP servers, beside .claude", installLocation("global", github.ModeMCP), filepath.Join(home, ".claude", lockFileName)},
+		{"project agents", installLocation("project", github.ModeAgents), filepath.Join(project, ".claude", lockFileName)},
+		{"project CLAUDE.md, beside .claude", installLocation("project", github.ModeMemory), filepath.Join(project, ".claude", lockFileName)},
+		{"nested inside a .claude directory", filepath.Join(other, ".claude", "agents", "team"), filepath.Join(other, ".claude", lockFileName)},
+		{"any other directory", other, filepath.Join(other, lockFileName)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := lockPath(tt.location); got != tt.want {
+				t.Errorf("lockPath(%q) = %q, want %q", tt.location, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLockFileRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".claude", lockFileName)
+
+	lock, err := readLockFile(path)
+	if err != nil {
+		t.Fatalf("missing lockfile: %v", err)
+	}
+	if lock.Version != lockVersion || len(lock.Entries) != 0 {
+		t.Fatalf("missing lockfile = %+v, want an empty one", lock)
+	}
+
+	installed := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	reviewer := LockEntry{Kind: "agents", Repo: "acme/tools", Path: ".claude/agents/reviewer.md", Commit: "aaa", SHA256: "1", Target: "agents/reviewer.md", InstalledAt: installed}
+	memory := LockEntry{Kind: "claude-md", Repo: "acme/tools", Path: "CLAUDE.md", Sections: []string{"Testing"}, SHA256: "2", Target: "CLAUDE.md", InstalledAt: installed}
+	lock.record(reviewer)
+	lock.record(memory)
+
+	// Reinstalling the same artifact replaces its entry
+	reviewer.Commit, reviewer.SHA256 = "bbb", "3"
+	lock.record(reviewer)
+
+	var stage stagedInstall
+	if err := lock.stage(&stage, path); err != nil {
+		t.Fatal(err)
+	}
+	if err := stage.commit(path); err != nil {
+		t.Fatal(err)
+	}
+
+	read, err := readLockFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &LockFile{Version: lockVersion, Entries: []LockEntry{reviewer, memory}}
+	if !reflect.DeepEqual(read, want) {
+		t.Errorf("read back %+v, want %+v", read, want)
+	}
+}
+
+func TestReadLockFileRejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"malformed", "{not json"},
+		{"newer version", `{"version": 99, "entries": []}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), lockFileName)
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := readLockFile(path); err == nil {
+				t.Error("read it, want an error")
+			}
+		})
+	}
+}
+
+func TestLockTarget(t *testing.T) {
+	root := t.TempDir()
+	lockFile := filepath.Join(root, ".claude", lockFileName)
+	outside := filepath.Join(t.TempDir(), "reviewer.md")
+
+	if got := lockTarget(lockFile, filepath.Join(root, ".claude", "agents", "reviewer.md")); got != "agents/reviewer.md" {
+		t.Errorf("beneath the lockfile = %q, want agents/reviewer.md", got)
+	}
+	if got := lockTarget(lockFile, outside); got != outside {
+		t.Errorf("elsewhere = %q, want %q", got, outside)
+	}
+	e := LockEntry{Target: "agents/reviewer.md"}
+	if got, want := entryTarget(lockFile, e), filepath.Join(root, ".claude", "agents", "reviewer.md"); got != want {
+		t.Errorf("entryTarget = %q, want %q", got, want)
+	}
+}
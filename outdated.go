@@ -0,0 +1,288 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"agent-search/github"
+)
+
+// ============================
+// Updating Installed Files
+// ============================
+
+// pendingUpdate is an installed artifact whose upstream has changed since it
+// was installed
+type pendingUpdate struct {
+	lockFile string
+	entry    LockEntry
+	commit   string     // Newest upstream commit touching the artifact
+	replaces string     // The upstream installed before, for merged kinds
+	diff     []diffLine // Local copy, or the installed upstream for merged kinds, against upstream
+	modified bool       // The local copy was edited after it was installed
+}
+
+// title names the update for lists
+func (u pendingUpdate) title() string {
+	name := u.entry.Repo + "/" + u.entry.Path
+	if u.entry.Server != "" {
+		name += " → " + u.entry.Server
+	}
+	return name
+}
+
+// lockFiles are the lockfiles `agentdl update` looks at for --to: the global
+// and project ones for "all", or the one for a single location. CLAUDE.md
+// installs into the directory holding the lockfile, so it stands in for them all.
+func lockFiles(to string) []string {
+	if to != "all" {
+		return []string{lockPath(installLocation(to, modeMemory))}
+	}
+
+	global := lockPath(installLocation("global", modeMemory))
+	project := lockPath(installLocation("project", modeMemory))
+	if global == project {
+		return []string{global}
+	}
+	return []string{global, project}
+}
+
+// checkUpdates looks up the newest upstream commit for every entry in the
+// lockfiles, five at a time, and diffs the ones that changed. Entries that
+// could not be checked are reported in the error without stopping the rest.
+func checkUpdates(ctx context.Context, files []string) ([]pendingUpdate, error) {
+	type job struct {
+		lockFile string
+		entry    LockEntry
+	}
+	var jobs []job
+	var errs []error
+	for _, f := range files {
+		lock, err := readLockFile(f)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, e := range lock.Entries {
+			jobs = append(jobs, job{f, e})
+		}
+	}
+
+	found := make([]*pendingUpdate, len(jobs))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, 5)
+
+	for i, j := range jobs {
+		wg.Add(1)
+		go func(i int, j job) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			u, err := checkEntry(ctx, j.lockFile, j.entry)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s/%s: %w", j.entry.Repo, j.entry.Path, err))
+				return
+			}
+			found[i] = u
+		}(i, j)
+	}
+	wg.Wait()
+
+	// Keep lockfile order
+	var updates []pendingUpdate
+	for _, u := range found {
+		if u != nil {
+			updates = append(updates, *u)
+		}
+	}
+	return updates, errors.Join(errs...)
+}
+
+// checkEntry returns the update for one lockfile entry, or nil when upstream
+// has not changed
+func checkEntry(ctx context.Context, lockFile string, e LockEntry) (*pendingUpdate, error) {
+	kind, ok := github.KindBySlug(e.Kind)
+	if !ok {
+		return nil, fmt.Errorf("unknown kind %q", e.Kind)
+	}
+
+	// A skill changes when anything in its directory does
+	watched := e.Path
+	if kind.Install == github.InstallSkill {
+		watched = path.Dir(e.Path)
+	}
+	commit, err := github.DefaultClient.LatestCommit(ctx, e.Repo, watched, e.Ref)
+	if err != nil {
+		return nil, err
+	}
+	if commit == "" || commit == e.Commit {
+		return nil, nil
+	}
+	// The lockfile pins the commit the whole repository was at, usually newer
+	// than the last one to touch the path, so compare the path's last change
+	// as of that commit instead
+	if e.Commit != "" {
+		installed, err := github.DefaultClient.LatestCommit(ctx, e.Repo, watched, e.Commit)
+		if err != nil {
+			return nil, err
+		}
+		if installed == commit {
+			return nil, nil
+		}
+	}
+
+	upstream, err := github.DefaultClient.Download(ctx, blobURL(e.Repo, commit, e.Path))
+	if err != nil {
+		return nil, err
+	}
+	// Skills are hashed as a whole directory, so only their commit can tell
+	if kind.Install != github.InstallSkill && contentHash(upstream) == e.SHA256 {
+		return nil, nil
+	}
+
+	u := &pendingUpdate{lockFile: lockFile, entry: e, commit: commit}
+	target := entryTarget(lockFile, e)
+
+	switch {
+	case kind.Install == github.InstallSkill:
+		local, _ := os.ReadFile(filepath.Join(target, path.Base(e.Path)))
+		u.diff = diffLines(string(local), upstream)
+
+	case replacesTarget(kind.Install, e.Path):
+		local, err := os.ReadFile(target)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		u.modified = err == nil && contentHash(string(local)) != e.SHA256
+		u.diff = diffLines(string(local), upstream)
+
+	default:
+		// Merged into a shared file, so the local copy is not comparable;
+		// show what changed upstream since the install instead
+		if e.Commit != "" {
+			u.replaces, err = github.DefaultClient.Download(ctx, blobURL(e.Repo, e.Commit, e.Path))
+			if err != nil {
+				return nil, err
+			}
+		}
+		u.diff = diffLines(u.replaces, upstream)
+	}
+	return u, nil
+}
+
+// entryTarget is the absolute path an entry was installed to
+func entryTarget(lockFile string, e LockEntry) string {
+	if filepath.IsAbs(e.Target) {
+		return e.Target
+	}
+	return filepath.Join(filepath.Dir(lockFile), filepath.FromSlash(e.Target))
+}
+
+// entrySelection rebuilds the selection and install location an entry was
+// installed from, pointed at commit
+func entrySelection(lockFile string, e LockEntry, commit string) (GlobalSelection, string) {
+	target := entryTarget(lockFile, e)
+	sel := GlobalSelection{
+		Repo:     e.Repo,
+		Path:     e.Path,
+		URL:      blobURL(e.Repo, commit, e.Path),
+		FileName: filepath.Base(target),
+		Source:   "update",
+		Server:   e.Server,
+		Sections: e.Sections,
+	}
+	location := filepath.Dir(target)
+
+	kind, _ := github.KindBySlug(e.Kind)
+	switch {
+	case kind.Install == github.InstallSkill:
+		sel.Dir = true
+	case kind.Install == github.InstallHooks && !github.IsSettingsFile(e.Path):
+		// Scripts sit beneath the first hooks/ directory below the lockfile,
+		// under whatever name a collision gave them
+		rel, err := filepath.Rel(filepath.Dir(lockFile), target)
+		if err != nil {
+			break
+		}
+		segments := strings.Split(filepath.ToSlash(rel), "/")
+		for i, seg := range segments[:len(segments)-1] {
+			if seg == "hooks" {
+				location = filepath.Join(append([]string{filepath.Dir(lockFile)}, segments[:i]...)...)
+				sel.FileName = path.Join(segments[i+1:]...)
+				break
+			}
+		}
+	}
+	return sel, location
+}
+
+// applyUpdates installs the accepted updates and records their new commits,
+// returning how many were applied
+func applyUpdates(ctx context.Context, updates []pendingUpdate) (int, error) {
+	byLock := make(map[string][]pendingUpdate)
+	var order []string
+	for _, u := range updates {
+		if _, ok := byLock[u.lockFile]; !ok {
+			order = append(order, u.lockFile)
+		}
+		byLock[u.lockFile] = append(byLock[u.lockFile], u)
+	}
+
+	count := 0
+	var errs []error
+	for _, lockFile := range order {
+		lock, err := readLockFile(lockFile)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		// Each lockfile's updates are installed together, or not at all
+		stage := &stagedInstall{}
+		applied := 0
+		for _, u := range byLock[lockFile] {
+			kind, _ := github.KindBySlug(u.entry.Kind)
+			sel, location := entrySelection(lockFile, u.entry, u.commit)
+			sel.Replaces = u.replaces
+			mark := stage.mark()
+			_, hash, err := installSelection(ctx, stage, sel, location, kind)
+			if err != nil {
+				stage.reset(mark)
+				errs = append(errs, fmt.Errorf("%s: %w", u.title(), err))
+				continue
+			}
+			applied++
+
+			entry := u.entry
+			entry.Commit = u.commit
+			entry.SHA256 = hash
+			entry.InstalledAt = time.Now().UTC()
+			lock.record(entry)
+		}
+
+		if applied == 0 {
+			continue
+		}
+		err = lock.stage(stage, lockFile)
+		if err == nil {
+			err = stage.commit(lockFile)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: rolled back: %w", lockFile, err))
+			continue
+		}
+		count += applied
+	}
+	return count, errors.Join(errs...)
+}
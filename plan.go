@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+// ============================
+// Install Plans
+// ============================
+
+// planChange is what an install would do to one file
+type planChange int
+
+const (
+	planNew       planChange = iota // The file does not exist yet
+	planUnchanged                   // The file already has exactly this content
+	planModified                    // The file would be replaced or merged into
+)
+
+// String names a change for listings
+func (c planChange) String() string {
+	switch c {
+	case planNew:
+		return "new"
+	case planUnchanged:
+		return "unchanged"
+	}
+	return "modified"
+}
+
+// plannedFile is one file an install would write
+type plannedFile struct {
+	dest    string
+	change  planChange
+	size    int // Bytes it would contain
+	added   int // Lines added, for modifications
+	removed int // Lines removed, for modifications
+}
+
+// planSelections works out what installing the selections into location would
+// write, downloading and merging everything exactly as an install would but
+// leaving the disk alone. The lockfile itself is left out of the plan. The
+// results say which selections could not be downloaded or staged.
+func planSelections(ctx context.Context, selections []GlobalSelection, location string, mode searchMode) ([]plannedFile, []downloadResult, error) {
+	results, stage, _, err := stageSelections(ctx, selections, location, mode, nil)
+	if err != nil {
+		return nil, results, err
+	}
+	files, err := stage.latest()
+	if err != nil {
+		return nil, results, err
+	}
+
+	lockFile := lockPath(location)
+	var plan []plannedFile
+	for _, f := range files {
+		if f.dest == lockFile {
+			continue
+		}
+		p := plannedFile{dest: f.dest, size: len(f.content)}
+		existing, err := os.ReadFile(f.dest)
+		switch {
+		case err != nil:
+			p.change = planNew
+		case contentHash(string(existing)) == contentHash(string(f.content)):
+			p.change = planUnchanged
+		default:
+			p.change = planModified
+			p.added, p.removed = diffStat(diffLines(string(existing), string(f.content)))
+		}
+		plan = append(plan, p)
+	}
+	return plan, results, nil
+}
+
+// planBytes totals the bytes a plan would write
+func planBytes(plan []plannedFile) int {
+	total := 0
+	for _, p := range plan {
+		total += p.size
+	}
+	return total
+}
+
+// formatBytes renders a byte count for people
+func formatBytes(n int) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
@@ -1,11 +1,15 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"os"
 	"path/filepath"
 
 	"agent-search/github"
+	"github.com/charmbracelet/bubbles/progress"
 	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
@@ -33,6 +37,9 @@ var (
 		bg:        lipgloss.Color("#1F2937"), // Dark gray
 	}
 
+	// Mode indicator colors, cycled through by search mode
+	modeColors = []lipgloss.Color{theme.secondary, theme.success, theme.primary, theme.error, theme.muted}
+
 	titleStyle = lipgloss.NewStyle().
 			Foreground(theme.primary).
 			Bold(true).
@@ -65,11 +72,16 @@ var (
 
 type searchResult github.Result
 
-type searchMode int
+// searchMode selects which kind of artifact to search for; see github.Kinds
+type searchMode = github.SearchMode
 
 const (
-	modeAgents searchMode = iota
-	modeCommands
+	modeAgents   = github.ModeAgents
+	modeCommands = github.ModeCommands
+	modeSkills   = github.ModeSkills
+	modeHooks    = github.ModeHooks
+	modeMCP      = github.ModeMCP
+	modeMemory   = github.ModeMemory
 )
 
 type locationOption int
@@ -80,25 +92,15 @@ const (
 	locationCustom
 )
 
-var locationPaths = map[locationOption]map[searchMode]func() string{
-	locationGlobal: {
-		modeAgents: func() string {
-			home, _ := os.UserHomeDir()
-			return filepath.Join(home, ".claude", "agents")
-		},
-		modeCommands: func() string {
-			home, _ := os.UserHomeDir()
-			return filepath.Join(home, ".claude", "commands")
-		},
-	},
-	locationCurrent: {
-		modeAgents: func() string {
-			return filepath.Join(".", ".claude", "agents")
-		},
-		modeCommands: func() string {
-			return filepath.Join(".", ".claude", "commands")
-		},
-	},
+// locationPath is where a mode installs to: the kind's default directory under
+// the home directory for global installs, or under the working directory
+func locationPath(loc locationOption, mode searchMode) string {
+	kind := github.KindFor(mode)
+	if loc == locationGlobal {
+		home, _ := os.UserHomeDir()
+		return filepath.Join(home, filepath.FromSlash(kind.GlobalDir))
+	}
+	return filepath.Join(".", filepath.FromSlash(kind.ProjectDir))
 }
 
 // ============================
@@ -119,24 +121,81 @@ const (
 	stateComplete
 	stateRepoViewer
 	stateConfirmLoseSelections
+	stateSections
+	stateUpdates
+	stateCollisions
+	statePlan
 )
 
 // ============================
 // Messages
 // ============================
 
+// errNoResults is reported when a search finishes without finding anything
+var errNoResults = errors.New("no results found")
+
+// searchResultsMsg carries one batch from a streaming search
 type searchResultsMsg struct {
-	results []searchResult
-	err     error
+	id       int // Generation of the search that produced these results
+	results  []searchResult
+	repoInfo map[string]github.RepoInfo // Set on the final batch once metadata is fetched
+	metadata map[string]github.Metadata // Frontmatter for earlier results, by github.ResultKey
+	updates  <-chan github.Update       // Stream to keep reading from
+	err      error
+}
+
+// searchDoneMsg signals that a streaming search has finished
+type searchDoneMsg struct {
+	id int
+}
+
+type rateLimitMsg struct {
+	limits github.RateLimits
 }
 
 type fileContentMsg struct {
 	content string
+	full    string // Untruncated, for diffing against a local copy
 	err     error
 }
 
-type downloadCompleteMsg struct {
+// sectionsMsg carries a CLAUDE.md split into pickable sections
+type sectionsMsg struct {
+	sections []github.Section
+	err      error
+}
+
+// updatesMsg carries the installed files whose upstream has changed
+type updatesMsg struct {
+	updates []pendingUpdate
+	err     error // Files that could not be checked
+}
+
+// updatesAppliedMsg reports how many accepted updates were installed
+type updatesAppliedMsg struct {
 	count int
+	err   error
+}
+
+// planMsg carries what installing the selections would write
+type planMsg struct {
+	id      int // Generation of the plan this answers
+	plan    []plannedFile
+	results []downloadResult // Selections that could not be planned are failed
+	err     error
+}
+
+// downloadProgressMsg reports that a file in a running download started or finished
+type downloadProgressMsg struct {
+	event   downloadEvent
+	updates <-chan tea.Msg // Stream to keep reading from
+}
+
+// downloadCompleteMsg reports what happened to each selection
+type downloadCompleteMsg struct {
+	results []downloadResult
+	err     error // Nothing was installed: the lockfile was unreadable or the batch rolled back
+	retry   bool  // Results are for a retry of the failed selections only
 }
 
 // ============================
@@ -157,13 +216,44 @@ type model struct {
 	customPath       string
 	customPathInput  textinput.Model
 	repoViewer       *RepoViewer
-	searchMode       searchMode        // Current search mode: agents or commands
-	locationChoice   int               // 0=global, 1=current, 2=custom
-	globalSelections *SelectionManager // Global selection manager
-	returnToState    state             // State to return to after confirmation
-	confirmChoice    int               // 0 = idgaf, 1 = oh shit go back
-	resultsOffset    int               // Scroll offset for results list
-	fileListCursor   int               // Separate cursor for file list view
+	searchMode       searchMode         // Current search mode: which artifact kind to search for
+	locationChoice   int                // 0=global, 1=current, 2=custom
+	globalSelections *SelectionManager  // Global selection manager
+	returnToState    state              // State to return to after confirmation
+	confirmChoice    int                // 0 = idgaf, 1 = oh shit go back
+	resultsOffset    int                // Scroll offset for results list
+	fileListCursor   int                // Separate cursor for file list view
+	searchID         int                // Generation of the current search; stale results are dropped
+	cancelSearch     context.CancelFunc // Cancels the in-flight search, nil when idle
+	rateLimits       github.RateLimits  // Latest known API budgets for the status bar
+	sections         []github.Section   // Sections of the CLAUDE.md being picked from
+	sectionPicks     map[int]bool       // Indices of the picked sections
+	sectionCursor    int                // Cursor in the section picker
+	installErr       error              // Why the last download installed nothing
+	updates          []pendingUpdate    // Upstream changes to installed files; nil while checking
+	updatePicks      map[int]bool       // Indices of the accepted updates
+	updateCursor     int                // Cursor in the update list
+	updateErr        error              // Files that could not be checked or updated
+	updateNote       string             // Outcome of the last apply
+	downloadTo       string             // Location of the current download
+	collisions       []collision        // Selections whose install target is taken
+	collisionChoice  int                // Index into collisionPolicies
+	downloadResults  []downloadResult   // What happened to each selection in the last download
+	downloading      []downloadResult   // Status of each file in the running download
+	cancelDownload   context.CancelFunc // Aborts the running download, nil once aborted
+	downloadBar      progress.Model     // Progress of the running download
+	planID           int                // Generation of the current plan; stale plans are dropped
+	cancelPlan       context.CancelFunc // Stops working out the current plan
+	plan             []plannedFile      // What installing into downloadTo would write; nil while planning
+	planFailed       []downloadResult   // Selections that could not be planned
+	planErr          error              // Why no plan could be made
+	planOffset       int                // Scroll offset of the plan list
+	previewResult    searchResult       // Result being previewed
+	previewRemote    string             // Its full content
+	previewLocal     string             // Installed copy it would replace; empty when there is none
+	localContent     string             // Contents of the installed copy
+	previewDiff      bool               // Showing the diff against the installed copy
+	previewSplit     bool               // Showing the diff side by side rather than unified
 }
 
 // ============================
@@ -195,11 +285,12 @@ func initialModel() model {
 		customPathInput:  customInput,
 		searchMode:       modeAgents, // Default to agents mode
 		globalSelections: NewSelectionManager(),
+		downloadBar:      progress.New(progress.WithGradient(string(theme.primary), string(theme.success))),
 	}
 }
 
 func (m model) Init() tea.Cmd {
-	return textinput.Blink
+	return tea.Batch(textinput.Blink, fetchRateLimits())
 }
 
 // ============================
@@ -214,15 +305,13 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.height = msg.Height
 		m.viewport.Width = msg.Width
 		m.viewport.Height = msg.Height - 4
+		if m.state == statePreview && m.previewDiff {
+			// A side-by-side diff is laid out for the width
+			m.showPreview()
+		}
 		// Adjust results scrolling on resize to keep cursor visible
 		if m.state == stateResults {
-			maxVisible := m.height - 8
-			if maxVisible < 5 {
-				maxVisible = 5
-			}
-			if maxVisible > 30 {
-				maxVisible = 30
-			}
+			maxVisible := m.resultsVisible()
 			maxOffset := 0
 			if len(m.results) > maxVisible {
 				maxOffset = len(m.results) - maxVisible
@@ -242,6 +331,15 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if msg.String() == "ctrl+c" {
 			return m, tea.Quit
 		}
+
+	// Search batches keep arriving whichever screen is showing
+	case searchResultsMsg:
+		return m.handleSearchResults(msg)
+	case searchDoneMsg:
+		return m.handleSearchDone(msg)
+	case rateLimitMsg:
+		m.rateLimits = msg.limits
+		return m, nil
 	}
 
 	// Route to state-specific handlers
@@ -268,6 +366,14 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m.updateRepoViewer(msg)
 	case stateConfirmLoseSelections:
 		return m.updateConfirmLoseSelections(msg)
+	case stateSections:
+		return m.updateSections(msg)
+	case stateUpdates:
+		return m.updateUpdates(msg)
+	case stateCollisions:
+		return m.updateCollisions(msg)
+	case statePlan:
+		return m.updatePlan(msg)
 	}
 
 	return m, nil
@@ -301,6 +407,14 @@ func (m model) View() string {
 		return m.viewRepoViewer()
 	case stateConfirmLoseSelections:
 		return m.viewConfirmLoseSelections()
+	case stateSections:
+		return m.viewSections()
+	case stateUpdates:
+		return m.viewUpdates()
+	case stateCollisions:
+		return m.viewCollisions()
+	case statePlan:
+		return m.viewPlan()
 	default:
 		return "Unknown state"
 	}
@@ -311,6 +425,32 @@ func (m model) View() string {
 // ============================
 
 func main() {
+	noCache := flag.Bool("no-cache", false, "bypass the on-disk cache of searches, stars and file contents")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: agentdl [flags]              browse interactively")
+		fmt.Fprintln(flag.CommandLine.Output(), "       agentdl search <keywords> [flags]")
+		fmt.Fprintln(flag.CommandLine.Output(), "       agentdl install owner/repo[:path][@ref]... [flags]")
+		fmt.Fprintln(flag.CommandLine.Output(), "       agentdl update [flags]")
+		fmt.Fprintln(flag.CommandLine.Output(), "       agentdl undo [flags]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	github.DefaultCache.Disabled = *noCache
+
+	// Pick the search backend once at startup: AGENTDL_BACKEND if set, otherwise
+	// http with a token, gh without one, and anonymous http when gh is missing
+	searcher, err := github.NewSearcher(os.Getenv("AGENTDL_BACKEND"))
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	github.SetSearcher(searcher)
+
+	// Subcommands run without a TTY
+	if flag.NArg() > 0 {
+		os.Exit(runSubcommand(flag.Args()))
+	}
+
 	p := tea.NewProgram(initialModel(), tea.WithAltScreen())
 	if _, err := p.Run(); err != nil {
 		fmt.Printf("Error: %v", err)
@@ -4,16 +4,22 @@ import (
 	"fmt"
 	"path/filepath"
 	"sort"
-	"strings"
+
+	"agent-search/github"
 )
 
 // GlobalSelection represents a file selected from any source (search results or repo browser)
 type GlobalSelection struct {
-	Repo     string // Repository name (e.g., "owner/repo")
-	Path     string // Full path in repo
-	URL      string // GitHub URL for downloading
-	FileName string // Just the filename
-	Source   string // "search" or "repo"
+	Repo     string   // Repository name (e.g., "owner/repo")
+	Path     string   // Full path in repo
+	URL      string   // GitHub URL for downloading
+	FileName string   // Just the filename, or the skill name for skills
+	Source   string   // "search", "repo" or "update"
+	Dir      bool     // A skill: Path is its SKILL.md and the whole directory is downloaded
+	Server   string   // An MCP server: only this entry of the config at Path is installed
+	Sections []string // CLAUDE.md sections to append, by heading; empty means the whole file
+	Ref      string   // Branch or tag chosen by the user; empty for the default branch
+	Replaces string   // For updates, the upstream file installed before; hooks merged from it are removed
 }
 
 // SelectionManager manages the global list of selected files
@@ -30,7 +36,7 @@ func NewSelectionManager() *SelectionManager {
 
 // Add adds a selection to the global list
 func (sm *SelectionManager) Add(sel GlobalSelection) {
-	key := sm.makeKey(sel.Repo, sel.Path)
+	key := sm.makeKey(sel.Repo, selectionPath(sel.Path, sel.Server))
 	sm.selections[key] = &sel
 }
 
@@ -42,7 +48,7 @@ func (sm *SelectionManager) Remove(repo, path string) {
 
 // Toggle toggles a selection (add if not present, remove if present)
 func (sm *SelectionManager) Toggle(sel GlobalSelection) bool {
-	key := sm.makeKey(sel.Repo, sel.Path)
+	key := sm.makeKey(sel.Repo, selectionPath(sel.Path, sel.Server))
 	if _, exists := sm.selections[key]; exists {
 		delete(sm.selections, key)
 		return false // now unselected
@@ -51,6 +57,15 @@ func (sm *SelectionManager) Toggle(sel GlobalSelection) bool {
 	return true // now selected
 }
 
+// Get returns the selection for a file, if it is selected
+func (sm *SelectionManager) Get(repo, path string) (GlobalSelection, bool) {
+	sel, ok := sm.selections[sm.makeKey(repo, path)]
+	if !ok {
+		return GlobalSelection{}, false
+	}
+	return *sel, true
+}
+
 // IsSelected checks if a file is selected
 func (sm *SelectionManager) IsSelected(repo, path string) bool {
 	key := sm.makeKey(repo, path)
@@ -74,7 +89,10 @@ func (sm *SelectionManager) GetAll() []GlobalSelection {
 		if result[i].Repo != result[j].Repo {
 			return result[i].Repo < result[j].Repo
 		}
-		return result[i].Path < result[j].Path
+		if result[i].Path != result[j].Path {
+			return result[i].Path < result[j].Path
+		}
+		return result[i].Server < result[j].Server
 	})
 	return result
 }
@@ -111,14 +129,44 @@ func (sm *SelectionManager) makeKey(repo, path string) string {
 	return fmt.Sprintf("%s:%s", repo, path)
 }
 
+// selectionPath is the path a selection is keyed by. MCP servers share their
+// config file, so the server name is appended to tell them apart.
+func selectionPath(path, server string) string {
+	if server == "" {
+		return path
+	}
+	return path + "#" + server
+}
+
+
+// blobURL is the web page for a file at ref, on the server the client talks to
+func blobURL(repo, ref, p string) string {
+	return github.DefaultClient.BlobURL(repo, ref, p)
+}
 
-// GetDownloadURL converts a GitHub file URL to raw download URL
-func GetDownloadURL(url string) string {
-	// Convert from: https://github.com/owner/repo/blob/main/path/file.md
-	// To: https://raw.githubusercontent.com/owner/repo/main/path/file.md
-	url = strings.Replace(url, "github.com", "raw.githubusercontent.com", 1)
-	url = strings.Replace(url, "/blob/", "/", 1)
-	return url
+// resultSelection builds the selection for a search result.
+// Skill results select their whole directory, named after the skill.
+func resultSelection(r searchResult) GlobalSelection {
+	sel := GlobalSelection{
+		Repo:     r.Repo,
+		Path:     r.Path,
+		URL:      r.URL,
+		FileName: ExtractFileName(r.Path),
+		Source:   "search",
+	}
+	if dir := github.SkillDir(r.Path); dir != "" {
+		sel.FileName = filepath.Base(dir)
+		sel.Dir = true
+	}
+	if github.IsHookScript(r.Path) {
+		// Hook scripts keep their layout beneath hooks/
+		sel.FileName = github.HookScriptPath(r.Path)
+	}
+	if r.MCPServer != "" {
+		sel.FileName = r.MCPServer
+		sel.Server = r.MCPServer
+	}
+	return sel
 }
 
 // ExtractFileName gets just the filename from a path
@@ -0,0 +1,325 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// ============================
+// Staged Installs and Undo
+// ============================
+
+// backupDirName holds one backup per install beside the lockfile, newest last
+const backupDirName = ".agentdl-backups"
+
+// maxBackups is how many installs can be undone; older backups are pruned
+const maxBackups = 10
+
+// stagedInstall collects the writes of a batch install in memory, so merges see
+// the files earlier selections staged, and then moves them all into place
+// together or not at all
+type stagedInstall struct {
+	files []stagedFile // In the order they were written; later writes win
+}
+
+// stagedFile is one pending write
+type stagedFile struct {
+	dest    string
+	content []byte
+	perm    os.FileMode
+}
+
+// read returns what dest will contain once the stage is committed
+func (s *stagedInstall) read(dest string) ([]byte, error) {
+	for i := len(s.files) - 1; i >= 0; i-- {
+		if s.files[i].dest == dest {
+			return s.files[i].content, nil
+		}
+	}
+	return os.ReadFile(dest)
+}
+
+// write stages content for dest
+func (s *stagedInstall) write(dest, content string, perm os.FileMode) error {
+	if info, err := os.Stat(dest); err == nil && info.IsDir() {
+		return fmt.Errorf("%s is a directory", dest)
+	}
+	s.files = append(s.files, stagedFile{dest: dest, content: []byte(content), perm: perm})
+	return nil
+}
+
+// mark returns a point to roll the stage back to with reset
+func (s *stagedInstall) mark() int {
+	return len(s.files)
+}
+
+// reset drops everything staged since mark, for a selection that failed partway
+func (s *stagedInstall) reset(mark int) {
+	s.files = s.files[:mark]
+}
+
+// commit writes the staged files to a temporary directory beside the lockfile,
+// checks them, and renames them into place, moving whatever they replace into a
+// new backup that undo can restore. If any step fails, everything already
+// moved is put back.
+func (s *stagedInstall) commit(lockFile string) error {
+	files, err := s.latest()
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return nil
+	}
+
+	lockDir := filepath.Dir(lockFile)
+	created, err := mkdirAll(lockDir)
+	if err != nil {
+		return err
+	}
+	backup := backupManifest{CreatedAt: time.Now().UTC(), Dirs: created}
+	backupDir := filepath.Join(lockDir, backupDirName, backup.CreatedAt.Format("20060102T150405.000000000"))
+	var stage string
+	fail := func(err error) error {
+		if stage != "" {
+			os.RemoveAll(stage)
+		}
+		return errors.Join(err, rollback(backupDir, backup))
+	}
+
+	stage, err = os.MkdirTemp(lockDir, ".agentdl-stage-")
+	if err != nil {
+		return fail(err)
+	}
+	defer os.RemoveAll(stage)
+
+	// Stage every file and read it back before touching anything
+	for i, f := range files {
+		dest := f.dest
+		staged := filepath.Join(stage, strconv.Itoa(i))
+		if err := os.WriteFile(staged, f.content, f.perm); err != nil {
+			return fail(fmt.Errorf("staging %s: %w", dest, err))
+		}
+		written, err := os.ReadFile(staged)
+		if err != nil || !bytes.Equal(written, f.content) {
+			return fail(fmt.Errorf("staging %s: the staged copy does not match", dest))
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Join(backupDir, "files"), 0755); err != nil {
+		return fail(err)
+	}
+	for i, f := range files {
+		dest := f.dest
+		dirs, err := mkdirAll(filepath.Dir(dest))
+		backup.Dirs = append(backup.Dirs, dirs...)
+		if err != nil {
+			return fail(err)
+		}
+
+		file := backupFile{Target: dest}
+		if _, err := os.Lstat(dest); err == nil {
+			file.Backup = filepath.Join("files", strconv.Itoa(i))
+			if err := os.Rename(dest, filepath.Join(backupDir, file.Backup)); err != nil {
+				return fail(fmt.Errorf("backing up %s: %w", dest, err))
+			}
+		}
+		backup.Files = append(backup.Files, file)
+
+		if err := os.Rename(filepath.Join(stage, strconv.Itoa(i)), dest); err != nil {
+			return fail(fmt.Errorf("installing %s: %w", dest, err))
+		}
+	}
+
+	data, err := json.MarshalIndent(backup, "", "  ")
+	if err != nil {
+		return fail(err)
+	}
+	if err := os.WriteFile(filepath.Join(backupDir, "manifest.json"), data, 0644); err != nil {
+		return fail(err)
+	}
+	pruneBackups(filepath.Join(lockDir, backupDirName))
+	return nil
+}
+
+// latest returns each staged file once, by absolute path, with its last write,
+// in the order the files were first written
+func (s *stagedInstall) latest() ([]stagedFile, error) {
+	index := make(map[string]int)
+	var files []stagedFile
+	for _, f := range s.files {
+		abs, err := filepath.Abs(f.dest)
+		if err != nil {
+			return nil, err
+		}
+		f.dest = abs
+		if i, ok := index[abs]; ok {
+			files[i] = f
+			continue
+		}
+		index[abs] = len(files)
+		files = append(files, f)
+	}
+	return files, nil
+}
+
+// backupManifest records what an install changed, so undo can put it back
+type backupManifest struct {
+	CreatedAt time.Time    `json:"createdAt"`
+	Files     []backupFile `json:"files"`
+	Dirs      []string     `json:"dirs,omitempty"` // Directories the install created
+}
+
+// backupFile is one file an install wrote
+type backupFile struct {
+	Target string `json:"target"`           // Absolute path
+	Backup string `json:"backup,omitempty"` // Previous content, relative to the backup; empty when the install created the file
+}
+
+// rollback undoes a commit that failed partway. The backup directory is only
+// deleted once every file is back, so nothing is lost if restoring fails.
+func rollback(backupDir string, backup backupManifest) error {
+	if err := restoreFiles(backupDir, backup); err != nil {
+		return fmt.Errorf("rolling back: %w (the old files are kept in %s)", err, backupDir)
+	}
+	if err := os.RemoveAll(backupDir); err != nil {
+		return fmt.Errorf("rolling back: %w", err)
+	}
+	os.Remove(filepath.Dir(backupDir)) // Only succeeds when no other backups are kept
+	if err := removeDirs(backup.Dirs); err != nil {
+		return fmt.Errorf("rolling back: %w", err)
+	}
+	return nil
+}
+
+// restoreBackup puts back the files a backup records and removes the
+// directories the install created
+func restoreBackup(backupDir string, backup backupManifest) error {
+	if err := restoreFiles(backupDir, backup); err != nil {
+		return err
+	}
+	return removeDirs(backup.Dirs)
+}
+
+// restoreFiles puts back the files a backup records, newest change first:
+// replaced files get their old content and created ones are removed
+func restoreFiles(backupDir string, backup backupManifest) error {
+	var errs []error
+	for i := len(backup.Files) - 1; i >= 0; i-- {
+		f := backup.Files[i]
+		var err error
+		if f.Backup != "" {
+			err = os.Rename(filepath.Join(backupDir, f.Backup), f.Target)
+		} else if err = os.Remove(f.Target); os.IsNotExist(err) {
+			err = nil
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// removeDirs removes the directories an install created, deepest first. A
+// directory that is gone already is fine; one that is not empty holds files the
+// install did not write and is left alone.
+func removeDirs(dirs []string) error {
+	sorted := append([]string(nil), dirs...)
+	sort.Slice(sorted, func(i, j int) bool { return len(sorted[i]) > len(sorted[j]) })
+	var errs []error
+	for _, d := range sorted {
+		err := os.Remove(d)
+		if err == nil || os.IsNotExist(err) {
+			continue
+		}
+		if entries, rerr := os.ReadDir(d); rerr == nil && len(entries) > 0 {
+			continue
+		}
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// undoLastInstall restores the newest backup beside lockFile and deletes it,
+// returning what it restored
+func undoLastInstall(lockFile string) (backupManifest, error) {
+	dir, ok := latestBackup(lockFile)
+	if !ok {
+		return backupManifest{}, fmt.Errorf("nothing to undo in %s", filepath.Dir(lockFile))
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "manifest.json"))
+	if err != nil {
+		return backupManifest{}, err
+	}
+	var backup backupManifest
+	if err := json.Unmarshal(data, &backup); err != nil {
+		return backupManifest{}, fmt.Errorf("%s: %w", dir, err)
+	}
+
+	if err := restoreBackup(dir, backup); err != nil {
+		return backup, err
+	}
+	return backup, os.RemoveAll(dir)
+}
+
+// latestBackup finds the newest complete backup beside lockFile
+func latestBackup(lockFile string) (string, bool) {
+	backups := listBackups(filepath.Join(filepath.Dir(lockFile), backupDirName))
+	if len(backups) == 0 {
+		return "", false
+	}
+	return backups[len(backups)-1], true
+}
+
+// listBackups lists the complete backups in root, oldest first. Their names are
+// timestamps, so they sort by age.
+func listBackups(root string) []string {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil
+	}
+	var backups []string
+	for _, e := range entries {
+		dir := filepath.Join(root, e.Name())
+		if _, err := os.Stat(filepath.Join(dir, "manifest.json")); e.IsDir() && err == nil {
+			backups = append(backups, dir)
+		}
+	}
+	sort.Strings(backups)
+	return backups
+}
+
+// pruneBackups keeps the newest maxBackups backups in root
+func pruneBackups(root string) {
+	backups := listBackups(root)
+	for len(backups) > maxBackups {
+		os.RemoveAll(backups[0])
+		backups = backups[1:]
+	}
+}
+
+// mkdirAll creates dir and any missing parents, returning the ones it created,
+// outermost first
+func mkdirAll(dir string) ([]string, error) {
+	var missing []string
+	for d := dir; ; d = filepath.Dir(d) {
+		if _, err := os.Stat(d); err == nil {
+			break
+		}
+		missing = append([]string{d}, missing...)
+		if filepath.Dir(d) == d {
+			break
+		}
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return missing, nil
+}
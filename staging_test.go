@@ -0,0 +1,137 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// gone marks a file that should not exist
+const gone = "\x00gone"
+
+func TestStagedInstall(t *testing.T) {
+	tests := []struct {
+		name       string
+		existing   map[string]string // Files on disk before the install
+		writes     [][2]string       // Staged writes, dest and content
+		wantErr    bool
+		wantCommit map[string]string // Disk after commit
+		wantUndo   map[string]string // Disk after undo; unused when the commit fails
+	}{
+		{
+			name:       "creates files and directories",
+			writes:     [][2]string{{"agents/a.md", "a"}, {"agents/nested/b.md", "b"}},
+			wantCommit: map[string]string{"agents/a.md": "a", "agents/nested/b.md": "b"},
+			wantUndo:   map[string]string{"agents/a.md": gone, "agents/nested/b.md": gone, "agents": gone},
+		},
+		{
+			name:       "replaces files and keeps the old content",
+			existing:   map[string]string{"agents/a.md": "old"},
+			writes:     [][2]string{{"agents/a.md", "new"}},
+			wantCommit: map[string]string{"agents/a.md": "new"},
+			wantUndo:   map[string]string{"agents/a.md": "old"},
+		},
+		{
+			name:       "later writes win",
+			writes:     [][2]string{{"a.md", "first"}, {"a.md", "second"}},
+			wantCommit: map[string]string{"a.md": "second"},
+			wantUndo:   map[string]string{"a.md": gone},
+		},
+		{
+			name:     "rolls back when a file cannot be installed",
+			existing: map[string]string{"agents/a.md": "old", "blocker": "file"},
+			writes: [][2]string{
+				{"agents/a.md", "new"},
+				{"fresh/c.md", "c"},
+				{"blocker/b.md", "b"},
+			},
+			wantErr: true,
+			wantCommit: map[string]string{
+				"agents/a.md": "old",
+				"fresh/c.md":  gone,
+				"fresh":       gone,
+				"blocker":     "file",
+				backupDirName: gone,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			for rel, content := range tt.existing {
+				dest := filepath.Join(root, rel)
+				if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(dest, []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var stage stagedInstall
+			for _, w := range tt.writes {
+				if err := stage.write(filepath.Join(root, w[0]), w[1], 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			lockFile := filepath.Join(root, lockFileName)
+			err := stage.commit(lockFile)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("commit error = %v, want error %v", err, tt.wantErr)
+			}
+			checkFiles(t, root, tt.wantCommit)
+			if tt.wantErr {
+				return
+			}
+
+			if _, err := undoLastInstall(lockFile); err != nil {
+				t.Fatalf("undo: %v", err)
+			}
+			checkFiles(t, root, tt.wantUndo)
+			if _, err := undoLastInstall(lockFile); err == nil {
+				t.Error("second undo succeeded, want nothing left to undo")
+			}
+		})
+	}
+}
+
+func TestPruneBackups(t *testing.T) {
+	root := t.TempDir()
+	lockFile := filepath.Join(root, lockFileName)
+	for i := 0; i < maxBackups+3; i++ {
+		stage := stagedInstall{}
+		if err := stage.write(filepath.Join(root, "a.md"), string(rune('a'+i)), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := stage.commit(lockFile); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := len(listBackups(filepath.Join(root, backupDirName))); got != maxBackups {
+		t.Errorf("kept %d backups, want %d", got, maxBackups)
+	}
+}
+
+// checkFiles compares files under root with want, by relative path
+func checkFiles(t *testing.T, root string, want map[string]string) {
+	t.Helper()
+	for rel, content := range want {
+		path := filepath.Join(root, rel)
+		if content == gone {
+			if _, err := os.Lstat(path); err == nil {
+				t.Errorf("%s exists, want it gone", rel)
+			}
+			continue
+		}
+		got, err := os.ReadFile(path)
+		if err != nil {
+			t.Errorf("%s: %v", rel, err)
+			continue
+		}
+		if string(got) != content {
+			t.Errorf("%s = %q, want %q", rel, got, content)
+		}
+	}
+}
@@ -1,8 +1,13 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"path"
+	"path/filepath"
 
+	"agent-search/github"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 )
@@ -20,19 +25,22 @@ func (m model) updateSearch(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case tea.KeyEsc:
 			return m, tea.Quit
 		case tea.KeyTab:
-			// Toggle search mode
-			if m.searchMode == modeAgents {
-				m.searchMode = modeCommands
-			} else {
-				m.searchMode = modeAgents
-			}
+			// Cycle through the artifact kinds
+			m.searchMode = github.NextMode(m.searchMode)
 			return m, nil
+		case tea.KeyCtrlU:
+			// Check installed files for upstream changes
+			m.state = stateUpdates
+			m.updates = nil
+			m.updateErr = nil
+			m.updateNote = ""
+			return m, checkForUpdates()
 		case tea.KeyEnter:
 			if m.searchInput.Value() != "" {
 				// Clear global selections when starting a new search
 				m.globalSelections.Clear()
 				m.state = stateSearching
-				return m, searchGitHub(m.searchInput.Value(), "all", m.searchMode)
+				return m, m.startSearch(m.searchInput.Value())
 			}
 			return m, nil
 		}
@@ -49,31 +57,126 @@ func (m model) updateSearch(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 func (m model) updateSearching(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
-	case searchResultsMsg:
-		if msg.err != nil {
-			m.err = msg.err
+	case tea.KeyMsg:
+		if msg.String() == "esc" {
+			m.stopSearch()
 			m.state = stateSearch
 			return m, nil
 		}
-		m.results = msg.results
-		m.cursor = 0
-		m.resultsOffset = 0
-		if len(m.results) > 0 {
-			m.state = stateResults
-		} else {
-			m.err = fmt.Errorf("no results found")
-			m.state = stateSearch
-		}
+	}
+
+	return m, nil
+}
+
+// startSearch cancels any in-flight search and launches a new one under a fresh generation ID
+func (m *model) startSearch(query string) tea.Cmd {
+	m.stopSearch()
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancelSearch = cancel
+	m.err = nil
+	m.results = []searchResult{}
+	m.cursor = 0
+	m.resultsOffset = 0
+	return searchGitHub(ctx, m.searchID, query, "all", m.searchMode)
+}
+
+// stopSearch cancels the in-flight search, if any, and bumps searchID so
+// batches it already sent are dropped
+func (m *model) stopSearch() {
+	m.searchID++
+	if m.cancelSearch != nil {
+		m.cancelSearch()
+		m.cancelSearch = nil
+	}
+}
+
+// searching reports whether a search is still streaming results
+func (m model) searching() bool {
+	return m.cancelSearch != nil
+}
+
+// handleSearchResults appends a streamed batch and keeps reading the stream
+func (m model) handleSearchResults(msg searchResultsMsg) (tea.Model, tea.Cmd) {
+	// Ignore results from a search that was cancelled or superseded
+	if msg.id != m.searchID {
 		return m, nil
+	}
 
-	case tea.KeyMsg:
-		if msg.String() == "esc" {
+	m.results = append(m.results, msg.results...)
+	m.rateLimits = github.CurrentRateLimits()
+	if msg.metadata != nil {
+		for i := range m.results {
+			if meta, ok := msg.metadata[github.ResultKey(github.Result(m.results[i]))]; ok {
+				m.results[i].Meta = &meta
+			}
+		}
+	}
+	if msg.repoInfo != nil {
+		m.applyRepoInfo(msg.repoInfo)
+	}
+
+	// A failure ends the search; keep whatever results already arrived
+	if msg.err != nil {
+		m.stopSearch()
+		m.err = msg.err
+		if len(m.results) == 0 {
 			m.state = stateSearch
 			return m, nil
 		}
 	}
 
-	return m, nil
+	// Show the results screen as soon as the first batch lands
+	if m.state == stateSearching && len(m.results) > 0 {
+		m.state = stateResults
+		m.cursor = 0
+		m.resultsOffset = 0
+	}
+
+	if msg.err != nil {
+		return m, nil
+	}
+	return m, waitForSearchUpdate(msg.id, msg.updates)
+}
+
+// handleSearchDone releases the finished search and reports empty searches
+func (m model) handleSearchDone(msg searchDoneMsg) (tea.Model, tea.Cmd) {
+	if msg.id != m.searchID {
+		return m, nil
+	}
+	m.stopSearch()
+	if m.state == stateSearching && len(m.results) == 0 {
+		m.err = errNoResults
+		m.state = stateSearch
+	}
+	return m, fetchRateLimits()
+}
+
+// applyRepoInfo fills in stars and metadata, re-ranks, and keeps the cursor on the same file
+func (m *model) applyRepoInfo(info map[string]github.RepoInfo) {
+	var current searchResult
+	if m.cursor < len(m.results) {
+		current = m.results[m.cursor]
+	}
+
+	results := make([]github.Result, len(m.results))
+	for i, r := range m.results {
+		results[i] = github.Result(r)
+	}
+	github.ApplyRepoInfo(results, info)
+	for i, r := range results {
+		m.results[i] = searchResult(r)
+		if r.Repo == current.Repo && r.Path == current.Path {
+			m.cursor = i
+		}
+	}
+
+	// Keep the cursor inside the visible window
+	maxVisible := m.resultsVisible()
+	if m.cursor < m.resultsOffset {
+		m.resultsOffset = m.cursor
+	} else if m.cursor >= m.resultsOffset+maxVisible {
+		m.resultsOffset = m.cursor - maxVisible + 1
+	}
 }
 
 func (m model) updateResults(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -88,6 +191,7 @@ func (m model) updateResults(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.confirmChoice = 1 // Default to "oh shit, go back"
 				return m, nil
 			}
+			m.stopSearch()
 			m.state = stateSearch
 			m.cursor = 0
 			m.resultsOffset = 0
@@ -108,52 +212,33 @@ func (m model) updateResults(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.cursor++
 			}
 			// adjust scroll offset based on visible window
-			maxVisible := m.height - 8
-			if maxVisible < 5 {
-				maxVisible = 5
-			}
-			if maxVisible > 30 {
-				maxVisible = 30
-			}
+			maxVisible := m.resultsVisible()
 			if m.cursor >= m.resultsOffset+maxVisible {
 				m.resultsOffset = m.cursor - maxVisible + 1
 			}
 
 		case " ", "space":
 			if m.cursor < len(m.results) {
-				result := m.results[m.cursor]
-				sel := GlobalSelection{
-					Repo:     result.Repo,
-					Path:     result.Path,
-					URL:      result.URL,
-					FileName: ExtractFileName(result.Path),
-					Source:   "search",
-				}
-				m.globalSelections.Toggle(sel)
+				m.globalSelections.Toggle(resultSelection(m.results[m.cursor]))
 			}
 
 		case "a":
 			// Check if all are selected
 			allSelected := true
 			for _, r := range m.results {
-				if !m.globalSelections.IsSelected(r.Repo, r.Path) {
+				if !m.globalSelections.IsSelected(r.Repo, selectionPath(r.Path, r.MCPServer)) {
 					allSelected = false
 					break
 				}
 			}
-			// Toggle all
+			// Toggle all, keeping selections already made so picked CLAUDE.md
+			// sections are not widened to the whole file
 			for _, r := range m.results {
-				sel := GlobalSelection{
-					Repo:     r.Repo,
-					Path:     r.Path,
-					URL:      r.URL,
-					FileName: ExtractFileName(r.Path),
-					Source:   "search",
-				}
+				key := selectionPath(r.Path, r.MCPServer)
 				if allSelected {
-					m.globalSelections.Remove(r.Repo, r.Path)
-				} else {
-					m.globalSelections.Add(sel)
+					m.globalSelections.Remove(r.Repo, key)
+				} else if !m.globalSelections.IsSelected(r.Repo, key) {
+					m.globalSelections.Add(resultSelection(r))
 				}
 			}
 
@@ -162,9 +247,23 @@ func (m model) updateResults(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if m.cursor < len(m.results) {
 				result := m.results[m.cursor]
 				m.state = statePreview
+				m.previewResult = result
+				m.previewDiff = false
+				m.previewLocal = ""
 				return m, fetchFileContent(result.URL)
 			}
 
+		case "s":
+			// Pick sections of a CLAUDE.md to import
+			if m.cursor < len(m.results) && github.KindFor(m.searchMode).Sections && github.IsMemoryFile(m.results[m.cursor].Path) {
+				result := m.results[m.cursor]
+				m.sections = nil
+				m.sectionCursor = 0
+				m.sectionPicks = make(map[int]bool)
+				m.state = stateSections
+				return m, fetchSections(result.URL)
+			}
+
 		case "v":
 			// View repository
 			if m.cursor < len(m.results) {
@@ -188,6 +287,43 @@ func (m model) updateResults(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// localCopy finds an installed file that installing r would replace: in the
+// location last downloaded to, the project or the global directory. Merged kinds
+// never replace anything.
+func (m model) localCopy(r searchResult) (local, content string) {
+	kind := github.KindFor(m.searchMode)
+	if !replacesTarget(kind.Install, r.Path) {
+		return "", ""
+	}
+
+	sel := resultSelection(r)
+	for _, location := range []string{m.downloadTo, locationPath(locationCurrent, m.searchMode), locationPath(locationGlobal, m.searchMode)} {
+		if location == "" {
+			continue
+		}
+		target := installTarget(kind.Install, location, sel)
+		if sel.Dir {
+			// A skill is previewed by its SKILL.md
+			target = filepath.Join(target, path.Base(r.Path))
+		}
+		if data, err := os.ReadFile(target); err == nil {
+			return target, string(data)
+		}
+	}
+	return "", ""
+}
+
+// showPreview fills the preview viewport with the file or, when toggled, its
+// diff against the installed copy
+func (m *model) showPreview() {
+	if m.previewDiff {
+		m.viewport.SetContent(m.renderLocalDiff())
+	} else {
+		m.viewport.SetContent(m.previewContent)
+	}
+	m.viewport.GotoTop()
+}
+
 func (m model) updatePreview(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case fileContentMsg:
@@ -197,8 +333,9 @@ func (m model) updatePreview(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 		m.previewContent = msg.content
-		m.viewport.SetContent(msg.content)
-		m.viewport.GotoTop()
+		m.previewRemote = msg.full
+		m.previewLocal, m.localContent = m.localCopy(m.previewResult)
+		m.showPreview()
 		return m, nil
 
 	case tea.KeyMsg:
@@ -207,6 +344,18 @@ func (m model) updatePreview(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.state = stateResults
 			m.previewContent = ""
 			return m, nil
+		case "d":
+			if m.previewLocal != "" {
+				m.previewDiff = !m.previewDiff
+				m.showPreview()
+			}
+			return m, nil
+		case "s":
+			if m.previewDiff {
+				m.previewSplit = !m.previewSplit
+				m.showPreview()
+			}
+			return m, nil
 		}
 	}
 
@@ -215,6 +364,194 @@ func (m model) updatePreview(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
+// updateSections handles the CLAUDE.md section picker. Confirming replaces the
+// file's selection with the picked sections, or deselects it when none are picked.
+func (m model) updateSections(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case sectionsMsg:
+		if msg.err != nil {
+			m.err = msg.err
+			m.state = stateResults
+			return m, nil
+		}
+		m.sections = msg.sections
+		// Tick the sections already picked, matched by heading
+		if m.cursor < len(m.results) {
+			result := m.results[m.cursor]
+			if sel, ok := m.globalSelections.Get(result.Repo, result.Path); ok {
+				for i, section := range m.sections {
+					m.sectionPicks[i] = containsString(sel.Sections, section.Heading)
+				}
+			}
+		}
+		return m, nil
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "esc":
+			m.state = stateResults
+			return m, nil
+
+		case "up", "k":
+			if m.sectionCursor > 0 {
+				m.sectionCursor--
+			}
+
+		case "down", "j":
+			if m.sectionCursor < len(m.sections)-1 {
+				m.sectionCursor++
+			}
+
+		case " ", "space":
+			if m.sectionCursor < len(m.sections) {
+				m.sectionPicks[m.sectionCursor] = !m.sectionPicks[m.sectionCursor]
+			}
+
+		case "a":
+			// Toggle all sections
+			all := len(m.sections) > 0
+			for i := range m.sections {
+				all = all && m.sectionPicks[i]
+			}
+			for i := range m.sections {
+				m.sectionPicks[i] = !all
+			}
+
+		case "enter":
+			if m.sections == nil || m.cursor >= len(m.results) {
+				return m, nil
+			}
+			result := m.results[m.cursor]
+			var picked []string
+			for i, section := range m.sections {
+				if m.sectionPicks[i] && !containsString(picked, section.Heading) {
+					picked = append(picked, section.Heading)
+				}
+			}
+			if len(picked) == 0 {
+				m.globalSelections.Remove(result.Repo, result.Path)
+			} else {
+				sel := resultSelection(result)
+				sel.Sections = picked
+				m.globalSelections.Add(sel)
+			}
+			m.state = stateResults
+			return m, nil
+		}
+	}
+
+	return m, nil
+}
+
+// updateUpdates handles the update screen: each changed file can be accepted or
+// skipped after reading its diff, and enter installs the accepted ones
+func (m model) updateUpdates(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case updatesMsg:
+		m.updates = msg.updates
+		if m.updates == nil {
+			m.updates = []pendingUpdate{}
+		}
+		m.updateErr = msg.err
+		m.updateCursor = 0
+		m.updatePicks = make(map[int]bool)
+		m.showUpdateDiff()
+		return m, nil
+
+	case updatesAppliedMsg:
+		m.updateNote = fmt.Sprintf("Updated %d file(s)", msg.count)
+		if msg.err != nil {
+			m.updateNote = fmt.Sprintf("Updated %d file(s); some failed", msg.count)
+		}
+		// Check again so applied updates drop off the list
+		m.updates = nil
+		m.updateErr = msg.err
+		return m, checkForUpdates()
+
+	case tea.KeyMsg:
+		if m.updates == nil {
+			if msg.String() == "esc" || msg.String() == "q" {
+				m.state = stateSearch
+			}
+			return m, nil
+		}
+
+		switch msg.String() {
+		case "q", "esc":
+			m.state = stateSearch
+			return m, nil
+
+		case "up", "k":
+			if m.updateCursor > 0 {
+				m.updateCursor--
+				m.showUpdateDiff()
+			}
+
+		case "down", "j":
+			if m.updateCursor < len(m.updates)-1 {
+				m.updateCursor++
+				m.showUpdateDiff()
+			}
+
+		case " ", "space":
+			if m.updateCursor < len(m.updates) {
+				m.updatePicks[m.updateCursor] = !m.updatePicks[m.updateCursor]
+			}
+
+		case "y", "n":
+			// Accept or skip, then move on to the next file
+			if m.updateCursor < len(m.updates) {
+				m.updatePicks[m.updateCursor] = msg.String() == "y"
+				if m.updateCursor < len(m.updates)-1 {
+					m.updateCursor++
+					m.showUpdateDiff()
+				}
+			}
+
+		case "a":
+			all := len(m.updates) > 0
+			for i := range m.updates {
+				all = all && m.updatePicks[i]
+			}
+			for i := range m.updates {
+				m.updatePicks[i] = !all
+			}
+
+		case "enter":
+			var accepted []pendingUpdate
+			for i, u := range m.updates {
+				if m.updatePicks[i] {
+					accepted = append(accepted, u)
+				}
+			}
+			if len(accepted) == 0 {
+				return m, nil
+			}
+			m.updates = nil
+			m.updateNote = ""
+			return m, applyAcceptedUpdates(accepted)
+
+		default:
+			// PgUp/PgDn and friends scroll the diff
+			var cmd tea.Cmd
+			m.viewport, cmd = m.viewport.Update(msg)
+			return m, cmd
+		}
+	}
+
+	return m, nil
+}
+
+// showUpdateDiff loads the diff of the update under the cursor into the viewport
+func (m *model) showUpdateDiff() {
+	if m.updateCursor >= len(m.updates) {
+		m.viewport.SetContent("")
+		return
+	}
+	m.viewport.SetContent(renderDiff(unifiedDiff(m.updates[m.updateCursor].diff, 3)))
+	m.viewport.GotoTop()
+}
+
 func (m model) updateLocation(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
@@ -227,6 +564,17 @@ func (m model) updateLocation(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.state = stateLocationFileList
 			m.fileListCursor = 0
 			return m, nil
+		case "p":
+			switch m.locationChoice {
+			case 0:
+				return m.startPlan(locationPath(locationGlobal, m.searchMode))
+			case 1:
+				return m.startPlan(locationPath(locationCurrent, m.searchMode))
+			}
+			// A custom path has to be typed first; tab there shows its plan
+			m.state = stateCustomPath
+			m.customPathInput.Focus()
+			return m, textinput.Blink
 		case "up", "k":
 			if m.locationChoice > 0 {
 				m.locationChoice--
@@ -239,12 +587,10 @@ func (m model) updateLocation(msg tea.Msg) (tea.Model, tea.Cmd) {
 			switch m.locationChoice {
 			case 0: // Global
 				m.location = locationGlobal
-				m.state = stateDownloading
-				return m, downloadSelectedFiles(m.results, locationPaths[locationGlobal][m.searchMode](), m.globalSelections)
+				return m.startDownload(locationPath(locationGlobal, m.searchMode))
 			case 1: // Current
 				m.location = locationCurrent
-				m.state = stateDownloading
-				return m, downloadSelectedFiles(m.results, locationPaths[locationCurrent][m.searchMode](), m.globalSelections)
+				return m.startDownload(locationPath(locationCurrent, m.searchMode))
 			case 2: // Custom
 				m.state = stateCustomPath
 				m.customPathInput.Focus()
@@ -256,6 +602,118 @@ func (m model) updateLocation(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// startDownload installs the selections into location, first asking how to
+// resolve any that would replace an existing file or each other
+func (m model) startDownload(location string) (tea.Model, tea.Cmd) {
+	m.downloadTo = location
+	m.collisions = findCollisions(m.globalSelections.GetAll(), location, github.KindFor(m.searchMode))
+	if len(m.collisions) > 0 {
+		m.collisionChoice = 0
+		m.state = stateCollisions
+		return m, nil
+	}
+	return m.beginDownload(m.globalSelections.GetAll(), nil, false)
+}
+
+// beginDownload starts installing selections into downloadTo in the background;
+// skipped selections are only reported
+func (m model) beginDownload(install, skipped []GlobalSelection, retry bool) (tea.Model, tea.Cmd) {
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancelDownload = cancel
+	m.downloading = make([]downloadResult, len(install))
+	for i, sel := range install {
+		m.downloading[i] = downloadResult{sel: sel}
+	}
+	m.state = stateDownloading
+	return m, startDownloads(ctx, install, skipped, m.downloadTo, m.searchMode, retry)
+}
+
+// startPlan works out what downloading the selections into location would
+// write, for the plan screen
+func (m model) startPlan(location string) (tea.Model, tea.Cmd) {
+	if m.cancelPlan != nil {
+		m.cancelPlan()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	m.planID++
+	m.cancelPlan = cancel
+	m.downloadTo = location
+	m.plan = nil
+	m.planFailed = nil
+	m.planErr = nil
+	m.planOffset = 0
+	m.state = statePlan
+	return m, planDownload(ctx, m.planID, m.globalSelections.GetAll(), location, m.searchMode)
+}
+
+// updatePlan handles the plan screen
+func (m model) updatePlan(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case planMsg:
+		if msg.id != m.planID {
+			return m, nil
+		}
+		m.cancelPlan = nil
+		m.plan = msg.plan
+		m.planFailed = msg.results
+		m.planErr = msg.err
+		if m.plan == nil {
+			m.plan = []plannedFile{}
+		}
+		return m, nil
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc", "q":
+			if m.cancelPlan != nil {
+				m.cancelPlan()
+				m.cancelPlan = nil
+			}
+			m.state = stateLocation
+			return m, nil
+		case "up", "k":
+			if m.planOffset > 0 {
+				m.planOffset--
+			}
+		case "down", "j":
+			if m.planOffset < len(m.plan)-1 {
+				m.planOffset++
+			}
+		case "enter":
+			if m.plan == nil {
+				return m, nil
+			}
+			return m.startDownload(m.downloadTo)
+		}
+	}
+	return m, nil
+}
+
+// updateCollisions handles the collision resolution screen
+func (m model) updateCollisions(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc":
+			m.state = stateLocation
+			return m, nil
+		case "up", "k":
+			if m.collisionChoice > 0 {
+				m.collisionChoice--
+			}
+		case "down", "j":
+			if m.collisionChoice < len(collisionPolicies)-1 {
+				m.collisionChoice++
+			}
+		case "enter":
+			policy := collisionPolicies[m.collisionChoice].policy
+			install, skipped := resolveCollisions(m.globalSelections.GetAll(), m.collisions, policy, m.downloadTo, github.KindFor(m.searchMode))
+			return m.beginDownload(install, skipped, false)
+		}
+	}
+	return m, nil
+}
+
 func (m model) updateLocationFileList(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
@@ -280,9 +738,13 @@ func (m model) updateCustomPath(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "enter":
 			if m.customPathInput.Value() != "" {
 				path := m.customPathInput.Value()
-				m.state = stateDownloading
-				return m, downloadSelectedFiles(m.results, path, m.globalSelections)
+				return m.startDownload(path)
 			}
+		case "tab":
+			if m.customPathInput.Value() != "" {
+				return m.startPlan(m.customPathInput.Value())
+			}
+			return m, nil
 		}
 	}
 
@@ -293,14 +755,36 @@ func (m model) updateCustomPath(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 func (m model) updateDownloading(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
+	case downloadProgressMsg:
+		m.downloading[msg.event.index] = msg.event.result
+		return m, waitForDownloadUpdate(msg.updates)
+
 	case downloadCompleteMsg:
+		if m.cancelDownload != nil {
+			m.cancelDownload()
+			m.cancelDownload = nil
+		}
 		m.state = stateComplete
+		m.installErr = msg.err
+		if msg.retry {
+			// Keep what was already saved or skipped; the retry replaces the failures
+			var kept []downloadResult
+			for _, r := range m.downloadResults {
+				if r.status != downloadFailed {
+					kept = append(kept, r)
+				}
+			}
+			msg.results = append(kept, msg.results...)
+		}
+		m.downloadResults = msg.results
 		return m, nil
 
 	case tea.KeyMsg:
-		if msg.String() == "esc" {
-			m.state = stateLocation
-			m.locationChoice = 0
+		// Stop the transfers; nothing is installed, and the completion screen
+		// follows once they wind down
+		if msg.String() == "esc" && m.cancelDownload != nil {
+			m.cancelDownload()
+			m.cancelDownload = nil
 			return m, nil
 		}
 		// Don't handle other keys
@@ -315,6 +799,17 @@ func (m model) updateComplete(msg tea.Msg) (tea.Model, tea.Cmd) {
 		switch msg.String() {
 		case "q":
 			return m, tea.Quit
+		case "r":
+			var failed []GlobalSelection
+			for _, r := range m.downloadResults {
+				if r.status == downloadFailed {
+					failed = append(failed, r.sel)
+				}
+			}
+			if len(failed) == 0 {
+				return m, nil
+			}
+			return m.beginDownload(failed, nil, true)
 		case "enter":
 			// Reset and go back to search
 			m.state = stateSearch
@@ -337,6 +832,7 @@ func (m model) updateRepoViewer(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	// Check for back to results message
 	if _, ok := msg.(backToResultsMsg); ok {
+		m.repoViewer.Close()
 		m.state = stateResults
 		m.repoViewer = nil
 		return m, nil
@@ -365,6 +861,7 @@ func (m model) updateConfirmLoseSelections(msg tea.Msg) (tea.Model, tea.Cmd) {
 				// idgaf - Clear selections and proceed
 				m.globalSelections.Clear()
 				if m.returnToState == stateSearch {
+					m.stopSearch()
 					m.state = stateSearch
 					m.cursor = 0
 					m.results = []searchResult{}
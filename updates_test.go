@@ -0,0 +1,60 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestSelectAllKeepsPickedSections(t *testing.T) {
+	memory := searchResult{Repo: "acme/tools", Path: "CLAUDE.md"}
+	agent := searchResult{Repo: "acme/tools", Path: ".claude/agents/reviewer.md"}
+
+	m := model{state: stateResults, globalSelections: NewSelectionManager()}
+	m.results = []searchResult{memory, agent}
+	picked := resultSelection(memory)
+	picked.Sections = []string{"Testing"}
+	m.globalSelections.Add(picked)
+
+	updated, _ := m.updateResults(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("a")})
+	m = updated.(model)
+
+	sel, ok := m.globalSelections.Get(memory.Repo, memory.Path)
+	if !ok || !reflect.DeepEqual(sel.Sections, []string{"Testing"}) {
+		t.Errorf("CLAUDE.md selection = %+v, %v; want its picked sections kept", sel, ok)
+	}
+	if !m.globalSelections.IsSelected(agent.Repo, agent.Path) {
+		t.Error("the agent was not selected")
+	}
+}
+
+func TestStaleSearchBatchesDropped(t *testing.T) {
+	m := model{state: stateSearching, globalSelections: NewSelectionManager()}
+	stale := m.searchID
+
+	// Escape, or a new search, moves on to the next generation
+	m.stopSearch()
+	current := m.searchID
+	if current == stale {
+		t.Fatal("stopSearch did not bump searchID")
+	}
+
+	batch := []searchResult{{Repo: "acme/tools", Path: ".claude/agents/reviewer.md"}}
+	updated, cmd := m.handleSearchResults(searchResultsMsg{id: stale, results: batch})
+	m = updated.(model)
+	if len(m.results) != 0 || cmd != nil || m.state != stateSearching {
+		t.Errorf("stale batch applied: %d results, state %v", len(m.results), m.state)
+	}
+	updated, _ = m.handleSearchDone(searchDoneMsg{id: stale})
+	m = updated.(model)
+	if m.searchID != current {
+		t.Error("a stale search's completion ended the current one")
+	}
+
+	updated, cmd = m.handleSearchResults(searchResultsMsg{id: current, results: batch})
+	m = updated.(model)
+	if len(m.results) != 1 || cmd == nil || m.state != stateResults {
+		t.Errorf("current batch: %d results, state %v; want it shown and the stream read on", len(m.results), m.state)
+	}
+}
@@ -1,9 +1,13 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"path/filepath"
 	"strings"
+	"time"
 
+	"agent-search/github"
 	"github.com/charmbracelet/lipgloss"
 )
 
@@ -24,15 +28,9 @@ func (m model) viewSearch() string {
 		Render("Discover Claude Agents on GitHub")
 
 	// Mode indicator
-	var modeText string
-	var modeStyle lipgloss.Style
-	if m.searchMode == modeAgents {
-		modeText = "[Agents]"
-		modeStyle = lipgloss.NewStyle().Foreground(theme.secondary).Bold(true)
-	} else {
-		modeText = "[Commands]"
-		modeStyle = lipgloss.NewStyle().Foreground(theme.success).Bold(true)
-	}
+	kind := github.KindFor(m.searchMode)
+	modeText := "[" + kind.Name + "]"
+	modeStyle := lipgloss.NewStyle().Foreground(modeColors[int(m.searchMode)%len(modeColors)]).Bold(true)
 	modeIndicator := modeStyle.Render(modeText)
 
 	// Build content using simple formatting
@@ -43,8 +41,8 @@ func (m model) viewSearch() string {
 			subtitle,
 			modeIndicator,
 			m.searchInput.View(),
-			errorStyle.Render(fmt.Sprintf("⚠ %v", m.err)),
-			helpStyle.Render("Tab: toggle mode • Enter: search • Esc: quit"),
+			errorStyle.Render(fmt.Sprintf("⚠ %v", m.err))+"\n"+helpStyle.Render(errorHint(m.err)),
+			helpStyle.Render("Tab: switch mode • Enter: search • Ctrl+U: updates • Esc: quit")+"\n"+m.viewRateLimits(),
 			lipgloss.NewStyle().Foreground(theme.muted).Italic(true).Render("Made w/ ♥ by WillyV3"),
 		)
 	} else {
@@ -53,7 +51,7 @@ func (m model) viewSearch() string {
 			subtitle,
 			modeIndicator,
 			m.searchInput.View(),
-			helpStyle.Render("Tab: toggle mode • Enter: search • Esc: quit"),
+			helpStyle.Render("Tab: switch mode • Enter: search • Ctrl+U: updates • Esc: quit")+"\n"+m.viewRateLimits(),
 			lipgloss.NewStyle().Foreground(theme.muted).Italic(true).Render("Made w/ ♥ by WillyV3"),
 		)
 	}
@@ -64,7 +62,7 @@ func (m model) viewSearch() string {
 
 func (m model) viewSearching() string {
 	title := titleStyle.Render("Searching GitHub...")
-	loadingText := "Finding .claude/agents files..."
+	loadingText := "Finding " + github.KindFor(m.searchMode).Noun + "..."
 
 	content := lipgloss.JoinVertical(
 		lipgloss.Center,
@@ -82,18 +80,16 @@ func (m model) viewResults() string {
 	var b strings.Builder
 
 	// Title
-	b.WriteString(titleStyle.Render(fmt.Sprintf("Found %d agent files", len(m.results))))
+	title := fmt.Sprintf("Found %d %s", len(m.results), github.KindFor(m.searchMode).Noun)
+	if m.searching() {
+		title += " (searching...)"
+	}
+	b.WriteString(titleStyle.Render(title))
 	b.WriteString("\n\n")
 
 	// Stable scrolling: keep an offset and only scroll
 	// when the cursor leaves the visible window
-	maxVisible := m.height - 8 // leave room for title/help/padding
-	if maxVisible < 5 {
-		maxVisible = 5
-	}
-	if maxVisible > 30 {
-		maxVisible = 30
-	}
+	maxVisible := m.resultsVisible()
 
 	// Clamp offset to a valid window based on current height
 	if m.resultsOffset < 0 {
@@ -120,7 +116,7 @@ func (m model) viewResults() string {
 
 		// Build line: checkbox + filename + stars
 		checkbox := "[ ]"
-		if m.globalSelections.IsSelected(r.Repo, r.Path) {
+		if m.globalSelections.IsSelected(r.Repo, selectionPath(r.Path, r.MCPServer)) {
 			checkbox = "[✓]"
 		}
 
@@ -128,6 +124,20 @@ func (m model) viewResults() string {
 		if r.Stars > 0 {
 			line += fmt.Sprintf(" ⭐ %d", r.Stars)
 		}
+		if sel, ok := m.globalSelections.Get(r.Repo, r.Path); ok && len(sel.Sections) > 0 {
+			line += fmt.Sprintf(" (%d sections)", len(sel.Sections))
+		}
+		if r.Meta != nil && r.Meta.Model != "" {
+			line += " · " + r.Meta.Model
+		}
+		if len(r.HookEvents) > 0 {
+			line += " [" + strings.Join(r.HookEvents, ", ") + "]"
+		}
+		if r.Archived {
+			line += " (archived)"
+		} else if r.Fork {
+			line += " (fork)"
+		}
 
 		// Render with selection
 		if i == m.cursor {
@@ -138,18 +148,110 @@ func (m model) viewResults() string {
 		b.WriteString("\n")
 	}
 
+	// Frontmatter of the highlighted result
+	if github.KindFor(m.searchMode).Frontmatter {
+		b.WriteString(m.viewResultDetail())
+	}
+
 	// Selection count
 	if count := m.globalSelections.Count(); count > 0 {
 		b.WriteString(fmt.Sprintf("\n%d selected\n", count))
 	}
 
+	// Errors that stopped the search early or broke a preview
+	if m.err != nil {
+		b.WriteString("\n" + errorStyle.Render(fmt.Sprintf("⚠ %v", m.err)))
+		b.WriteString("\n" + helpStyle.Render(errorHint(m.err)))
+	}
+
 	// Help
 	b.WriteString("\n")
-	b.WriteString(helpStyle.Render("↑↓ move • space select • enter download • v repo • p preview • esc back"))
+	help := "↑↓ move • space select • enter download • v repo • p preview • esc back"
+	if github.KindFor(m.searchMode).Sections {
+		help = "↑↓ move • space select • s pick sections • enter download • v repo • p preview • esc back"
+	}
+	b.WriteString(helpStyle.Render(help))
+	b.WriteString("\n" + m.viewRateLimits())
 
 	return b.String()
 }
 
+// detailPaneHeight is the number of lines viewResultDetail takes up
+const detailPaneHeight = 4
+
+// resultsVisible is how many results fit on screen, leaving room for the title,
+// help and status bar, and the detail pane for kinds with frontmatter
+func (m model) resultsVisible() int {
+	maxVisible := m.height - 8
+	if github.KindFor(m.searchMode).Frontmatter {
+		maxVisible -= detailPaneHeight
+	}
+	if maxVisible < 5 {
+		maxVisible = 5
+	}
+	if maxVisible > 30 {
+		maxVisible = 30
+	}
+	return maxVisible
+}
+
+// viewResultDetail shows the highlighted result's name, description, tools and
+// model, padded to detailPaneHeight lines so the list does not jump
+func (m model) viewResultDetail() string {
+	lines := []string{""}
+	if m.cursor < len(m.results) {
+		r := m.results[m.cursor]
+		width := m.width - 4
+		if width < 20 {
+			width = 20
+		}
+
+		if r.Meta == nil {
+			lines = append(lines, dimStyle.Render("  No frontmatter"))
+		} else {
+			name := r.Meta.Name
+			if name == "" {
+				name = ExtractFileName(r.Path)
+			}
+			lines = append(lines, "  "+selectedStyle.Render(name))
+			if r.Meta.Description != "" {
+				lines = append(lines, "  "+truncate(strings.Join(strings.Fields(r.Meta.Description), " "), width))
+			}
+
+			var facts []string
+			if len(r.Meta.Tools) > 0 {
+				facts = append(facts, "tools: "+strings.Join(r.Meta.Tools, ", "))
+			}
+			if r.Meta.Model != "" {
+				facts = append(facts, "model: "+r.Meta.Model)
+			}
+			if len(facts) > 0 {
+				lines = append(lines, dimStyle.Render("  "+truncate(strings.Join(facts, " • "), width)))
+			}
+		}
+	}
+	for len(lines) < detailPaneHeight {
+		lines = append(lines, "")
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// truncate shortens s to width runes, marking the cut with an ellipsis. Widths
+// below one leave nothing, so callers can pass what is left of a narrow terminal.
+func truncate(s string, width int) string {
+	if width < 1 {
+		return ""
+	}
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+	if width == 1 {
+		return "…"
+	}
+	return string(runes[:width-1]) + "…"
+}
+
 func (m model) viewPreview() string {
 	if m.previewContent == "" {
 		return "Loading preview..."
@@ -157,9 +259,326 @@ func (m model) viewPreview() string {
 
 	var b strings.Builder
 	title := titleStyle.Render("📄 Preview")
+	help := "↑↓/PgUp/PgDn: scroll • esc/q: back"
+	switch {
+	case m.previewDiff:
+		title = titleStyle.Render("📄 Changes to " + m.previewLocal)
+		layout := "side by side"
+		if m.previewSplit {
+			layout = "unified"
+		}
+		help = "↑↓/PgUp/PgDn: scroll • d: file • s: " + layout + " • esc/q: back"
+	case m.previewLocal != "":
+		title += dimStyle.Render("  installed at " + m.previewLocal)
+		help = "↑↓/PgUp/PgDn: scroll • d: diff against installed copy • esc/q: back"
+	}
 	b.WriteString(title + "\n\n")
 	b.WriteString(m.viewport.View())
-	b.WriteString("\n" + helpStyle.Render("↑↓/PgUp/PgDn: scroll • esc/q: back"))
+	b.WriteString("\n" + helpStyle.Render(help))
+	return b.String()
+}
+
+// renderLocalDiff shows what installing the previewed file would change in the
+// installed copy: its frontmatter fields, then the lines
+func (m model) renderLocalDiff() string {
+	var b strings.Builder
+	if fields := frontmatterChanges(m.localContent, m.previewRemote); len(fields) > 0 {
+		b.WriteString(selectedStyle.Render("Frontmatter"))
+		b.WriteString("\n")
+		for _, f := range fields {
+			switch f[0] {
+			case '+':
+				b.WriteString(lipgloss.NewStyle().Foreground(theme.success).Render(f))
+			case '-':
+				b.WriteString(lipgloss.NewStyle().Foreground(theme.error).Render(f))
+			default:
+				b.WriteString(lipgloss.NewStyle().Foreground(theme.secondary).Render(f))
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+
+	d := diffLines(m.localContent, m.previewRemote)
+	if !diffChanged(d) {
+		b.WriteString(dimStyle.Render("The installed copy is identical"))
+		return b.String()
+	}
+	added, removed := diffStat(d)
+	b.WriteString(dimStyle.Render(fmt.Sprintf("+%d -%d lines (- installed, + remote)", added, removed)))
+	b.WriteString("\n\n")
+	if m.previewSplit {
+		b.WriteString(renderSideBySide(diffRows(d), m.width))
+	} else {
+		b.WriteString(renderDiff(unifiedDiff(d, 3)))
+	}
+	return b.String()
+}
+
+// renderSideBySide lays diff rows out in two columns, the installed copy on the
+// left and the remote file on the right
+func renderSideBySide(rows []diffRow, width int) string {
+	col := max((width-3)/2, 10)
+	cell := lipgloss.NewStyle().Width(col).MaxWidth(col)
+	added := cell.Foreground(theme.success)
+	removed := cell.Foreground(theme.error)
+	gutter := dimStyle.Render(" │ ")
+
+	// Tabs would throw the columns out of line
+	fit := func(s string) string {
+		return truncate(strings.ReplaceAll(s, "\t", "    "), col)
+	}
+
+	out := make([]string, len(rows))
+	for i, r := range rows {
+		left, right := cell.Render(fit(r.left)), cell.Render(fit(r.right))
+		if r.changed {
+			if r.hasLeft {
+				left = removed.Render(fit(r.left))
+			}
+			if r.hasRight {
+				right = added.Render(fit(r.right))
+			}
+		}
+		out[i] = left + gutter + right
+	}
+	return strings.Join(out, "\n")
+}
+
+func (m model) viewSections() string {
+	if m.sections == nil {
+		return "Loading sections..."
+	}
+
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("📑 Pick sections to import"))
+	b.WriteString("\n\n")
+
+	if len(m.sections) == 0 {
+		b.WriteString(dimStyle.Render("  (empty file)"))
+		b.WriteString("\n")
+	}
+
+	for i, s := range m.sections {
+		checkbox := "[ ]"
+		if m.sectionPicks[i] {
+			checkbox = "[x]"
+		}
+
+		heading := s.Heading
+		if s.Level == 0 {
+			heading = "(intro)"
+		} else {
+			heading = strings.Repeat("#", s.Level) + " " + heading
+		}
+		lines := strings.Count(s.Text, "\n") + 1
+		line := fmt.Sprintf("%s %s %s", checkbox, heading, dimStyle.Render(fmt.Sprintf("(%d lines)", lines)))
+
+		if i == m.sectionCursor {
+			b.WriteString(selectedStyle.Render("> " + line))
+		} else {
+			b.WriteString(normalStyle.Render("  " + line))
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+	b.WriteString(helpStyle.Render("↑↓ move • space pick • a all • enter confirm • esc cancel"))
+	return b.String()
+}
+
+// viewUpdates lists installed files with upstream changes above the diff of the
+// one under the cursor
+func (m model) viewUpdates() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("🔄 Updates"))
+	b.WriteString("\n\n")
+
+	if m.updateNote != "" {
+		b.WriteString(successStyle.Render(m.updateNote))
+		b.WriteString("\n")
+	}
+	if m.updateErr != nil {
+		problems := strings.Split(m.updateErr.Error(), "\n")
+		line := "⚠ " + problems[0]
+		if len(problems) > 1 {
+			line += fmt.Sprintf(" (and %d more)", len(problems)-1)
+		}
+		b.WriteString(errorStyle.Render(truncate(line, max(m.width, 20))))
+		b.WriteString("\n")
+	}
+
+	if m.updates == nil {
+		b.WriteString("Checking installed files for upstream changes...")
+		return b.String()
+	}
+	if len(m.updates) == 0 {
+		b.WriteString(normalStyle.Render("Everything is up to date"))
+		b.WriteString("\n")
+		b.WriteString(helpStyle.Render("esc back"))
+		return b.String()
+	}
+
+	// Keep the cursor inside a short list so the diff gets the room
+	const listHeight = 6
+	start := 0
+	if m.updateCursor >= listHeight {
+		start = m.updateCursor - listHeight + 1
+	}
+	end := min(start+listHeight, len(m.updates))
+
+	for i := start; i < end; i++ {
+		u := m.updates[i]
+		checkbox := "[ ]"
+		if m.updatePicks[i] {
+			checkbox = "[x]"
+		}
+		added, removed := diffStat(u.diff)
+		stat := fmt.Sprintf("%s → %s +%d -%d", shortSHA(u.entry.Commit), shortSHA(u.commit), added, removed)
+		line := fmt.Sprintf("%s %s %s", checkbox, truncate(u.title(), max(m.width/2, 20)), dimStyle.Render(stat))
+		if u.modified {
+			line += " " + errorStyle.Render("edited locally")
+		}
+
+		if i == m.updateCursor {
+			b.WriteString(selectedStyle.Render("> " + line))
+		} else {
+			b.WriteString(normalStyle.Render("  " + line))
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+
+	vp := m.viewport
+	vp.Height = max(3, m.height-strings.Count(b.String(), "\n")-4)
+	b.WriteString(vp.View())
+	b.WriteString("\n")
+	b.WriteString(helpStyle.Render("↑↓ move • y accept • n skip • space toggle • a all • PgUp/PgDn scroll • enter apply • esc back"))
+	return b.String()
+}
+
+// renderDiff colors unified diff lines: additions green, removals red
+func renderDiff(lines []string) string {
+	added := lipgloss.NewStyle().Foreground(theme.success)
+	removed := lipgloss.NewStyle().Foreground(theme.error)
+	hunk := lipgloss.NewStyle().Foreground(theme.primary)
+
+	out := make([]string, len(lines))
+	for i, l := range lines {
+		switch {
+		case strings.HasPrefix(l, "@@"):
+			out[i] = hunk.Render(l)
+		case strings.HasPrefix(l, "+"):
+			out[i] = added.Render(l)
+		case strings.HasPrefix(l, "-"):
+			out[i] = removed.Render(l)
+		default:
+			out[i] = l
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+// viewPlan lists every file a download would write and how it would change
+func (m model) viewPlan() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("🗺 Install Plan"))
+	b.WriteString("\n\n")
+
+	if m.plan == nil {
+		b.WriteString(fmt.Sprintf("Working out what would change in %s...\n", m.downloadTo))
+		b.WriteString(helpStyle.Render("esc back"))
+		return b.String()
+	}
+	if m.planErr != nil {
+		b.WriteString(errorStyle.Render(fmt.Sprintf("⚠ %v", m.planErr)))
+		b.WriteString("\n")
+		b.WriteString(helpStyle.Render("esc back"))
+		return b.String()
+	}
+
+	counts := make(map[planChange]int)
+	for _, p := range m.plan {
+		counts[p.change]++
+	}
+	b.WriteString(fmt.Sprintf("%d files, %s into %s\n", len(m.plan), formatBytes(planBytes(m.plan)), m.downloadTo))
+	b.WriteString(dimStyle.Render(fmt.Sprintf("%d new • %d modified • %d unchanged", counts[planNew], counts[planModified], counts[planUnchanged])))
+	b.WriteString("\n\n")
+
+	// Scroll the list, leaving room for failures and the help line
+	visible := max(m.height-12-min(len(m.planFailed), 5), 3)
+	end := min(m.planOffset+visible, len(m.plan))
+	for _, p := range m.plan[m.planOffset:end] {
+		target := p.dest
+		if rel, err := filepath.Rel(m.downloadTo, p.dest); err == nil && !strings.HasPrefix(rel, "..") {
+			target = rel
+		}
+		var change string
+		switch p.change {
+		case planNew:
+			change = successStyle.Render(fmt.Sprintf("%-10s", "new"))
+		case planModified:
+			change = selectedStyle.Render(fmt.Sprintf("%-10s", fmt.Sprintf("+%d -%d", p.added, p.removed)))
+		default:
+			change = dimStyle.Render(fmt.Sprintf("%-10s", "unchanged"))
+		}
+		size := dimStyle.Render(fmt.Sprintf("%9s", formatBytes(p.size)))
+		b.WriteString(fmt.Sprintf("  %s %s  %s\n", change, size, truncate(target, max(m.width-26, 20))))
+	}
+	if end < len(m.plan) {
+		b.WriteString(dimStyle.Render(fmt.Sprintf("  ... and %d more", len(m.plan)-end)))
+		b.WriteString("\n")
+	}
+
+	if len(m.planFailed) > 0 {
+		b.WriteString("\n")
+		for i, r := range m.planFailed {
+			if i == 5 {
+				b.WriteString(dimStyle.Render(fmt.Sprintf("  ... and %d more failed", len(m.planFailed)-5)))
+				b.WriteString("\n")
+				break
+			}
+			b.WriteString(errorStyle.Render(truncate(fmt.Sprintf("  ✗ %s/%s: %v", r.sel.Repo, r.sel.Path, r.err), max(m.width-2, 20))))
+			b.WriteString("\n")
+		}
+	}
+
+	b.WriteString(helpStyle.Render("↑/↓ scroll • enter download • esc back"))
+	return b.String()
+}
+
+// viewCollisions lists the selections whose install target is taken and the
+// ways to resolve them
+func (m model) viewCollisions() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("⚠ Name collisions"))
+	b.WriteString("\n\n")
+
+	selections := m.globalSelections.GetAll()
+	b.WriteString(fmt.Sprintf("%d of %d files would replace something in %s:\n\n", len(m.collisions), len(selections), m.downloadTo))
+
+	const maxListed = 8
+	for i, c := range m.collisions {
+		if i == maxListed {
+			b.WriteString(dimStyle.Render(fmt.Sprintf("  ... and %d more", len(m.collisions)-maxListed)))
+			b.WriteString("\n")
+			break
+		}
+		b.WriteString(normalStyle.Render("  " + truncate(c.describe(selections, m.downloadTo), max(m.width-4, 20))))
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\nHow should they be installed?\n\n")
+	for i, p := range collisionPolicies {
+		if i == m.collisionChoice {
+			b.WriteString(selectedStyle.Render("> " + p.help))
+		} else {
+			b.WriteString(normalStyle.Render("  " + p.help))
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString(helpStyle.Render("↑↓ choose • enter install • esc back"))
 	return b.String()
 }
 
@@ -179,17 +598,17 @@ func (m model) viewLocation() string {
 
 	// Option 1
 	if m.locationChoice == 0 {
-		b.WriteString(selectedStyle.Render("> Global: " + locationPaths[locationGlobal][m.searchMode]()))
+		b.WriteString(selectedStyle.Render("> Global: " + locationPath(locationGlobal, m.searchMode)))
 	} else {
-		b.WriteString(normalStyle.Render("  Global: " + locationPaths[locationGlobal][m.searchMode]()))
+		b.WriteString(normalStyle.Render("  Global: " + locationPath(locationGlobal, m.searchMode)))
 	}
 	b.WriteString("\n")
 
 	// Option 2
 	if m.locationChoice == 1 {
-		b.WriteString(selectedStyle.Render("> Current: " + locationPaths[locationCurrent][m.searchMode]()))
+		b.WriteString(selectedStyle.Render("> Current: " + locationPath(locationCurrent, m.searchMode)))
 	} else {
-		b.WriteString(normalStyle.Render("  Current: " + locationPaths[locationCurrent][m.searchMode]()))
+		b.WriteString(normalStyle.Render("  Current: " + locationPath(locationCurrent, m.searchMode)))
 	}
 	b.WriteString("\n")
 
@@ -202,7 +621,7 @@ func (m model) viewLocation() string {
 	b.WriteString("\n")
 
 	b.WriteString("\n")
-	b.WriteString(helpStyle.Render("↑/↓ select • v view files • enter download • esc cancel"))
+	b.WriteString(helpStyle.Render("↑/↓ select • v view files • p plan • enter download • esc cancel"))
 
 	return b.String()
 }
@@ -245,7 +664,7 @@ func (m model) viewCustomPath() string {
 		"",
 		m.customPathInput.View(),
 		"",
-		helpStyle.Render("Enter: confirm • Esc: back"),
+		helpStyle.Render("Enter: confirm • Tab: plan • Esc: back"),
 	)
 
 	return lipgloss.Place(
@@ -259,7 +678,46 @@ func (m model) viewCustomPath() string {
 
 func (m model) viewDownloading() string {
 	title := titleStyle.Render("⬇️ Downloading Files...")
-	loadingText := "Saving agent files..."
+
+	total := len(m.downloading)
+	done := countResults(m.downloading, downloadSaved) + countResults(m.downloading, downloadFailed)
+	percent := 1.0
+	if total > 0 {
+		percent = float64(done) / float64(total)
+	}
+	bar := m.downloadBar
+	bar.Width = max(min(m.width-8, 60), 10)
+	loadingText := fmt.Sprintf("%d of %d files saved to %s", countResults(m.downloading, downloadSaved), total, m.downloadTo)
+
+	// Keep the window of files on the first one still in flight
+	visible := max(m.height-14, 3)
+	start := 0
+	for i, r := range m.downloading {
+		if r.status == downloadPending || r.status == downloadRunning {
+			start = max(i-1, 0)
+			break
+		}
+		start = max(i-visible+1, 0)
+	}
+	var files []string
+	for _, r := range m.downloading[start:min(start+visible, total)] {
+		name := truncate(r.sel.Repo+"/"+r.sel.Path, bar.Width-2)
+		switch r.status {
+		case downloadPending:
+			files = append(files, dimStyle.Render("· "+name))
+		case downloadRunning:
+			files = append(files, selectedStyle.Render("⬇ "+name))
+		case downloadSaved:
+			files = append(files, successStyle.Render("✓ "+name))
+		case downloadFailed:
+			files = append(files, errorStyle.Render("✗ "+name))
+		}
+	}
+
+	help := "Esc: cancel"
+	if m.cancelDownload == nil {
+		help = "Cancelling..."
+	}
 
 	content := lipgloss.JoinVertical(
 		lipgloss.Center,
@@ -267,36 +725,79 @@ func (m model) viewDownloading() string {
 		"",
 		loadingText,
 		"",
-		helpStyle.Render("Please wait..."),
+		bar.ViewAs(percent),
+		"",
+		lipgloss.JoinVertical(lipgloss.Left, files...),
+		"",
+		helpStyle.Render(help),
 	)
 
 	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, content)
 }
 
 func (m model) viewComplete() string {
-	var path string
-	switch m.location {
-	case locationGlobal:
-		path = locationPaths[locationGlobal][m.searchMode]()
-	case locationCurrent:
-		path = locationPaths[locationCurrent][m.searchMode]()
-	case locationCustom:
-		path = m.customPath
+	path := m.downloadTo
+	saved := countResults(m.downloadResults, downloadSaved)
+	skipped := countResults(m.downloadResults, downloadSkipped)
+	failed := countResults(m.downloadResults, downloadFailed)
+
+	title := successStyle.Render("✅ Download Complete!")
+	if failed > 0 {
+		title = errorStyle.Render(fmt.Sprintf("⚠️  %d of %d Downloads Failed", failed, len(m.downloadResults)))
 	}
+	details := fmt.Sprintf("%d files saved to:\n%s", saved, path)
 
-	// Calculate total files that should have been downloaded
-	totalFiles := m.globalSelections.Count()
+	if skipped > 0 {
+		details += fmt.Sprintf("\n%d skipped because the name was taken", skipped)
+	}
 
-	title := successStyle.Render("✅ Download Complete!")
-	details := fmt.Sprintf("%d files saved to:\n%s", totalFiles, path)
+	lockNote := dimStyle.Render("Sources recorded in " + lockPath(path) + "\nRun agentdl undo to revert this install")
+	if m.installErr != nil {
+		lockNote = errorStyle.Render(fmt.Sprintf("Nothing was installed: %v", m.installErr))
+	} else if saved == 0 {
+		lockNote = ""
+	}
+
+	// List each failure with its reason, leaving room for the rest of the screen
+	var failures []string
+	maxFailures := max(m.height-14, 3)
+	for _, r := range m.downloadResults {
+		if r.status != downloadFailed {
+			continue
+		}
+		if len(failures) == maxFailures {
+			failures = append(failures, dimStyle.Render(fmt.Sprintf("...and %d more", failed-maxFailures)))
+			break
+		}
+		line := fmt.Sprintf("✗ %s/%s: %v", r.sel.Repo, r.sel.Path, r.err)
+		failures = append(failures, errorStyle.Render(truncate(line, max(m.width-4, 20))))
+	}
+
+	help := "Press Enter to search again • q to quit"
+	if failed > 0 {
+		help = "r: retry failed • Enter: search again • q: quit"
+	}
 
 	content := lipgloss.JoinVertical(
 		lipgloss.Center,
 		title,
 		"",
 		normalStyle.Render(details),
+		lockNote,
+	)
+	if len(failures) > 0 {
+		content = lipgloss.JoinVertical(
+			lipgloss.Center,
+			content,
+			"",
+			lipgloss.JoinVertical(lipgloss.Left, failures...),
+		)
+	}
+	content = lipgloss.JoinVertical(
+		lipgloss.Center,
+		content,
 		"",
-		helpStyle.Render("Press Enter to search again • q to quit"),
+		helpStyle.Render(help),
 	)
 
 	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, content)
@@ -377,3 +878,45 @@ func (m model) viewConfirmLoseSelections() string {
 		boxed,
 	)
 }
+
+// errorHint suggests a fix for errors surfaced by the github package
+func errorHint(err error) string {
+	var rateErr *github.RateLimitError
+	switch {
+	case errors.As(err, &rateErr):
+		if wait := rateErr.RetryAfter(); wait > 0 {
+			return fmt.Sprintf("Try again in %s, or set GITHUB_TOKEN for a higher limit", wait.Round(time.Second))
+		}
+		return "Wait a minute and try again, or set GITHUB_TOKEN for a higher limit"
+	case errors.Is(err, github.ErrUnauthenticated):
+		return "Set GITHUB_TOKEN or GH_TOKEN, or run `gh auth login`"
+	case errors.Is(err, github.ErrBackendMissing):
+		return "Install the GitHub CLI (https://cli.github.com) or set AGENTDL_BACKEND=http with GITHUB_TOKEN"
+	case errors.Is(err, errNoResults):
+		return "Try fewer or different keywords, or press Tab to switch mode"
+	}
+	return "Try again; if it keeps failing, check your network connection"
+}
+
+// viewRateLimits renders the status bar showing the remaining search and core quota
+func (m model) viewRateLimits() string {
+	render := func(name string, rl github.RateLimit) string {
+		if !rl.Known() {
+			return dimStyle.Render(name + " ?")
+		}
+		text := fmt.Sprintf("%s %d/%d", name, rl.Remaining, rl.Limit)
+		if rl.Remaining == 0 {
+			text += " (resets " + rl.Reset.Local().Format("15:04") + ")"
+		}
+		// Flag budgets below 10%
+		if rl.Remaining*10 < rl.Limit {
+			return errorStyle.Render(text)
+		}
+		return dimStyle.Render(text)
+	}
+
+	return dimStyle.Render("API quota: ") +
+		render("search", m.rateLimits.Search) +
+		dimStyle.Render(" • ") +
+		render("core", m.rateLimits.Core)
+}
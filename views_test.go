@@ -0,0 +1,59 @@
+package main
+
+import "testing"
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		s     string
+		width int
+		want  string
+	}{
+		{"reviewer.md", 20, "reviewer.md"},
+		{"reviewer.md", 11, "reviewer.md"},
+		{"reviewer.md", 8, "reviewe…"},
+		{"→ café", 4, "→ c…"},
+		{"reviewer.md", 1, "…"},
+		{"reviewer.md", 0, ""},
+		{"reviewer.md", -26, ""},
+		{"", 0, ""},
+	}
+
+	for _, tt := range tests {
+		if got := truncate(tt.s, tt.width); got != tt.want {
+			t.Errorf("truncate(%q, %d) = %q, want %q", tt.s, tt.width, got, tt.want)
+		}
+	}
+}
+
+// TestNarrowTerminal renders screens at widths a terminal can really have,
+// including 0 before the first WindowSizeMsg, which must not panic
+func TestNarrowTerminal(t *testing.T) {
+	failed := downloadResult{
+		sel:    GlobalSelection{Repo: "acme/tools", Path: ".claude/agents/reviewer.md"},
+		status: downloadFailed,
+		err:    errDownloadCancelled,
+	}
+	for width := 0; width <= 12; width++ {
+		m := model{width: width, height: 10}
+		m.downloadTo = "/tmp/agents"
+		m.plan = []plannedFile{{dest: "/tmp/agents/reviewer.md", change: planNew, size: 10}}
+		m.planFailed = []downloadResult{failed}
+		m.viewPlan()
+
+		m.downloading = []downloadResult{failed, {sel: failed.sel, status: downloadRunning}}
+		m.viewDownloading()
+
+		m.updates = []pendingUpdate{{entry: LockEntry{Repo: "acme/tools", Path: ".claude/agents/reviewer.md"}}}
+		m.updatePicks = map[int]bool{}
+		m.updateErr = errDownloadCancelled
+		m.viewUpdates()
+
+		m.downloadResults = []downloadResult{failed}
+		m.viewComplete()
+
+		m.globalSelections = NewSelectionManager()
+		m.globalSelections.Add(failed.sel)
+		m.collisions = []collision{{index: 0, target: "/tmp/agents/reviewer.md", existing: true}}
+		m.viewCollisions()
+	}
+}